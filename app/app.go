@@ -3,9 +3,14 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -17,103 +22,596 @@ var (
 	DefaultGracePeriod = 3 * time.Second
 	// DefaultShutdownTimeout is the default value for the timeout during shutdown.
 	DefaultShutdownTimeout = 5 * time.Second
+	// DefaultStartupTimeout is the default value for the timeout during startup.
+	DefaultStartupTimeout = 30 * time.Second
+	// DefaultReloadTimeout is the default value for the timeout during reload.
+	DefaultReloadTimeout = 5 * time.Second
+	// DefaultHealthCheckTimeout is the default value for the timeout of each health check.
+	DefaultHealthCheckTimeout = 2 * time.Second
+	// DefaultWatchdogMargin is the default time the shutdown may overrun
+	// ShutdownTimeout before the watchdog forces the process to exit.
+	DefaultWatchdogMargin = 5 * time.Second
+	// DefaultSignals are the signals that trigger a graceful shutdown by default.
+	DefaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
 	// This is the default app.
 	defaultApp *App
 )
 
-type ShutdownHandler func(context.Context) error
+var (
+	// ErrNilMainLoop is returned when the app is run with a nil main loop.
+	ErrNilMainLoop = errors.New("main loop is nil")
+	// ErrLoopExitedUnexpectedly is the main loop error when it returned nil
+	// by itself with WithRequireRunningLoop.
+	ErrLoopExitedUnexpectedly = errors.New("main loop exited unexpectedly")
+)
 
-type MainLoopFunc func() error
+// Exit codes returned by Run.
+const (
+	// ExitOK means the app terminated cleanly.
+	ExitOK = 0
+	// ExitMainLoopError means the main loop returned an error.
+	ExitMainLoopError = 1
+	// ExitShutdownError means at least one shutdown handler failed.
+	ExitShutdownError = 2
+	// ExitForced means the process was hard-killed by repeated shutdown signals.
+	ExitForced = 3
+	// ExitStartupError means a startup handler failed and the main loop never ran.
+	ExitStartupError = 4
+)
+
+// MainLoopFunc is the application main loop. The context it receives is
+// canceled as soon as a shutdown signal arrives, before the grace period
+// starts, so the loop can begin draining its work right away.
+// context.Cause tells why the context was canceled, see ErrShuttingDown.
+// The loop should return promptly once the context is done.
+type MainLoopFunc func(context.Context) error
 
 // App represents an application with a main loop and a shutdown routine
 type App struct {
-	GracePeriod      time.Duration
-	ShutdownTimeout  time.Duration
-	shutdownHandlers []ShutdownHandler
-	logger           *slog.Logger
+	GracePeriod     time.Duration
+	ShutdownTimeout time.Duration
+	ShutdownOrder   ShutdownOrder
+	// StartupTimeout bounds the time all startup handlers may take together.
+	// Zero means no timeout.
+	StartupTimeout time.Duration
+	// ReloadTimeout bounds the time all reload handlers may take together.
+	// Zero means no timeout.
+	ReloadTimeout time.Duration
+	// HealthCheckTimeout bounds each health check run by Health.
+	// Zero means no timeout.
+	HealthCheckTimeout time.Duration
+	// ConcurrentShutdown runs all shutdown handlers at the same time instead
+	// of one after the other. ShutdownOrder is ignored when it is set.
+	ConcurrentShutdown bool
+	// Signals are the signals that trigger a graceful shutdown.
+	// On Windows only os.Interrupt (Ctrl-C) and syscall.SIGTERM (delivered
+	// when the console is closed or the user logs off) are ever received.
+	Signals []os.Signal
+
+	ctx    context.Context
+	logger *slog.Logger
+	// lifecycleLogger logs the messages of the app itself, when set.
+	lifecycleLogger *slog.Logger
+	// loggerFlush persists the buffered log lines, when set.
+	loggerFlush func() error
+	logConfig   logConfig
+	clock       Clock
+	// quietEmptyShutdown disables the warning logged when shutting down
+	// without any shutdown handler.
+	quietEmptyShutdown bool
+	// panicOnShutdownError makes Shutdown panic with its error.
+	panicOnShutdownError bool
+	// onTerminated is called once the app terminated, when set.
+	onTerminated func(err error)
+	// maxLifetime triggers a graceful shutdown once the main loop ran for
+	// that long, when positive.
+	maxLifetime time.Duration
+	// equalTimeBudget shares the time left equally among the remaining
+	// shutdown handlers.
+	equalTimeBudget bool
+	// forceExitFunc hard-kills the process, os.Exit when nil.
+	forceExitFunc func(code int)
+	// watchdogMargin is how long the shutdown may overrun ShutdownTimeout
+	// before the watchdog forces the process to exit.
+	watchdogMargin time.Duration
+	// graceJitter is the maximum random offset added to the grace period.
+	graceJitter time.Duration
+	// jitterRand draws the grace period offset, the global source when nil.
+	jitterRand *rand.Rand
+	// requireRunningLoop makes a main loop returning nil by itself an error.
+	requireRunningLoop bool
+	// controlSocket is the path of the control socket, if any.
+	controlSocket string
+	// quietStartupSummary disables the configuration summary logged once
+	// the app started.
+	quietStartupSummary bool
+	// envDefaults reads the settings not given with options from the
+	// environment.
+	envDefaults        bool
+	gracePeriodSet     bool
+	shutdownTimeoutSet bool
+	// signalMappings override the default signal actions.
+	signalMappings []signalMapping
+	// reExecSignal triggers ReExec with reExecFiles, when set.
+	reExecSignal os.Signal
+	reExecFiles  []*os.File
+	ready        atomic.Bool
+	inflight     inflightTracker
+	state        atomic.Int32
+	metrics      lifecycleMetrics
+	// metricsRecorder is called on every lifecycle event, when set.
+	metricsRecorder MetricsRecorder
+
+	mu                sync.Mutex
+	startupHandlers   []StartupHandler
+	reloadHandlers    []ReloadHandler
+	errorReporters    []ErrorReporter
+	graceStartHooks   []GraceHook
+	graceEndHooks     []GraceHook
+	shutdownVetoes    []ShutdownVeto
+	healthChecks      []namedHealthCheck
+	contextDecorators []ContextDecorator
+	group             *RunGroup
+	drainHandlers     []namedShutdownHandler
+	shutdownHandlers  []namedShutdownHandler
+	// lastHandlerID is the id of the last registered shutdown handler.
+	lastHandlerID HandlerID
+	// cycleStarted is set once the app started: the drain and shutdown
+	// handlers registered from then on are forgotten when it restarts.
+	cycleStarted bool
+	// shutdownStarted is set once Shutdown took the handlers to run, and
+	// shutdownFinished once it ran the second phase ones.
+	shutdownStarted     bool
+	shutdownFinished    bool
+	secondPhaseHandlers []namedShutdownHandler
+	// timings are the durations of the shutdown handlers of the last shutdown.
+	timings map[string]time.Duration
+	// appCtx is the context returned by Context.
+	appCtx       context.Context
+	cancelAppCtx context.CancelCauseFunc
+	// resumed is closed when the app resumes, nil when it is not paused.
+	resumed      chan struct{}
+	trigger      chan string
+	restarts     chan struct{}
+	done         chan struct{}
+	doneOnce     sync.Once
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewApp creates an app configured with the default values and then the given options.
+// Canceling ctx initiates a graceful shutdown, just like a signal would.
+// NewApp panics if the options map a signal to conflicting actions.
+func NewApp(ctx context.Context, opts ...Option) *App {
+	a := &App{
+		ctx:                ctx,
+		GracePeriod:        DefaultGracePeriod,
+		ShutdownTimeout:    DefaultShutdownTimeout,
+		ShutdownOrder:      OrderLIFO,
+		StartupTimeout:     DefaultStartupTimeout,
+		ReloadTimeout:      DefaultReloadTimeout,
+		HealthCheckTimeout: DefaultHealthCheckTimeout,
+		Signals:            slices.Clone(DefaultSignals),
+		clock:              realClock{},
+		watchdogMargin:     DefaultWatchdogMargin,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.validateSignalActions()
+	logger := a.logger
+	if logger == nil {
+		logger = a.lifecycleLogger
+	}
+	if logger == nil {
+		logger = a.logConfig.newLogger()
+	}
+	a.logger = a.logConfig.enrich(logger)
+	if a.lifecycleLogger != nil {
+		a.lifecycleLogger = a.logConfig.enrich(a.lifecycleLogger)
+	}
+	a.applyEnvDefaults()
+	return a
 }
 
-// NewDefaultApp creates and sets the default app.
-func NewDefaultApp(ctx context.Context) {
-	defaultApp = &App{
-		logger: slog.New(
-			slog.NewJSONHandler(os.Stdout, nil),
-		),
+// NewDefaultApp creates and sets the default app, see NewApp.
+// Its logger becomes the one returned by the package Logger function.
+func NewDefaultApp(ctx context.Context, opts ...Option) {
+	defaultApp = NewApp(ctx, opts...)
+	packageLogger.Store(defaultApp.logger)
+}
+
+// SetLogger replaces the logger used by the app.
+// A nil logger falls back to slog.Default(). The app metadata set with
+// WithAppMetadata is added to it.
+// It should be called before RunAndWait.
+func (a *App) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
 	}
-	defaultApp.GracePeriod = DefaultGracePeriod
-	defaultApp.ShutdownTimeout = DefaultShutdownTimeout
+	a.logger = a.logConfig.enrich(logger)
 }
 
+// RunAndWait runs the main loop and blocks until it returns or a shutdown
+// signal is received, then calls the shutdown handlers.
 func (a *App) RunAndWait(mainLoop MainLoopFunc) {
-	if defaultApp == nil {
-		panic("default app not initialized")
+	_ = a.RunE(a.baseContext(), mainLoop)
+}
+
+// RunE behaves like RunAndWait but also shuts down when ctx is canceled, and
+// returns the startup, main loop and shutdown errors joined together.
+// It returns ErrNilMainLoop right away if mainLoop is nil.
+func (a *App) RunE(ctx context.Context, mainLoop MainLoopFunc) error {
+	return a.run(ctx, mainLoop).err()
+}
+
+// Run behaves like RunAndWait and returns the exit code the process should
+// terminate with, so it can be used as os.Exit(a.Run(mainLoop)).
+// It returns ExitOK on clean termination, ExitStartupError when a startup
+// handler failed, ExitMainLoopError when the main loop failed and
+// ExitShutdownError when a shutdown handler failed.
+func (a *App) Run(mainLoop MainLoopFunc) int {
+	return a.run(a.baseContext(), mainLoop).exitCode()
+}
+
+// runResult holds the errors of each phase of the app lifecycle.
+type runResult struct {
+	cause       Cause
+	startupErr  error
+	loopErr     error
+	shutdownErr error
+}
+
+func (r runResult) err() error {
+	return errors.Join(r.startupErr, r.loopErr, r.shutdownErr)
+}
+
+func (r runResult) exitCode() int {
+	switch {
+	case r.startupErr != nil:
+		return ExitStartupError
+	case r.loopErr != nil:
+		return ExitMainLoopError
+	case r.shutdownErr != nil:
+		return ExitShutdownError
+	default:
+		return ExitOK
 	}
-	a.logger.Info("[app] Starting run and wait.")
+}
 
-	errs := make(chan error)
+// Wait blocks until a shutdown signal is received and then calls the
+// shutdown handlers, like RunAndWait but without a main loop. It suits
+// programs that only start background servers from their startup handlers
+// or before calling it.
+func (a *App) Wait() {
+	a.lifecycle(a.baseContext(), nil)
+}
 
-	go func() {
-		defer func() {
-			recover()
+// run runs the whole app lifecycle around mainLoop.
+// A nil main loop is rejected right away, without running the app, which
+// terminates all the same: Done is closed and WithOnTerminated is called.
+func (a *App) run(ctx context.Context, mainLoop MainLoopFunc) runResult {
+	if mainLoop == nil {
+		a.log().Error("Cannot run the app, its main loop is nil!",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.RunAndWait"),
+		)
+		res := runResult{loopErr: ErrNilMainLoop, cause: Cause{Kind: CauseMainLoopError, Err: ErrNilMainLoop}}
+		a.terminate(res)
+		return res
+	}
+	return a.lifecycle(ctx, func(ctx context.Context) <-chan error {
+		errs := make(chan error, 1)
+		go func() {
+			a.log().Info("Application main loop starting now!")
+			errs <- a.callMainLoop(ctx, mainLoop)
 		}()
+		return errs
+	})
+}
 
-		a.logger.Info("Application main loop starting now!")
-		if mainLoop == nil {
-			errs <- errors.New("main loop is nil")
-			return
-		}
-		errs <- mainLoop()
-	}()
+// lifecycle runs the whole app lifecycle: startup handlers, main loop and
+// shutdown handlers. The main loop is started by launch, which reports its
+// result on the returned channel; a nil launch means there is no main loop.
+// Canceling ctx, or the context the app was created with, shuts the app down.
+func (a *App) lifecycle(ctx context.Context, launch func(context.Context) <-chan error) (res runResult) {
+	a.log().Info("[app] Starting run and wait.")
+	defer func() { a.terminate(res) }()
 
-	notifyCtx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
+	// Canceling the context the app was created with shuts it down too.
+	parent := ctx
+	ctx, cancelCtx := context.WithCancelCause(parent)
+	defer cancelCtx(nil)
+	if base := a.baseContext(); base != parent {
+		stop := context.AfterFunc(base, func() {
+			cancelCtx(context.Cause(base))
+		})
+		defer stop()
+	}
+	ctx = a.decorateContext(withShutdownBudget(withLogger(ctx, a.Logger()), a.shutdownBudget()))
+	// The shutdown handlers must still run once the parent context is canceled.
+	shutdownCtx := context.WithoutCancel(ctx)
+	errs, cancelLoop, startupErr := a.startCycle(ctx, launch)
+	// cancelLoop changes on every restart.
+	defer func() { cancelLoop(ErrShuttingDown) }()
+	if startupErr != nil {
+		return a.startupFailed(shutdownCtx, startupErr)
+	}
 
-	var err error
-	ctx := context.Background()
-	select {
-	case <-notifyCtx.Done():
-		a.logger.Info("Graceful shutdown signal received! Awaiting for grace period to end.")
-		time.Sleep(a.GracePeriod)
-		a.logger.Info("Grace period is over, initiating shutdown procedures...")
-		err = a.Shutdown(ctx)
-	case err = <-errs:
-		a.logger.Error("Main Loop finished by itself, initiating shutdown procedures...",
-			slog.String("error", err.Error()))
-		err = a.Shutdown(ctx)
+	actions := a.signalActions()
+	sigs := make(chan os.Signal, 1)
+	defer notifySignals(sigs, signalsWith(actions, ActionGracefulShutdown))()
+	bypassVetoes := false
+
+	reloads := make(chan os.Signal, 1)
+	defer notifySignals(reloads, signalsWith(actions, ActionReload))()
+
+	forceExits := make(chan os.Signal, 1)
+	defer notifySignals(forceExits, signalsWith(actions, ActionForceExit))()
+
+	pauses := make(chan os.Signal, 1)
+	defer notifySignals(pauses, signalsWith(actions, ActionTogglePause))()
+
+	if ignored := signalsWith(actions, ActionIgnore); len(ignored) > 0 {
+		signal.Ignore(ignored...)
+		defer signal.Reset(ignored...)
+	}
+
+	// A nil channel never receives, leaving re-exec disabled, which it always
+	// is on platforms without exec(2).
+	var reExecs chan os.Signal
+	if a.reExecSignal != nil && reExecSupported {
+		reExecs = make(chan os.Signal, 1)
+		signal.Notify(reExecs, a.reExecSignal)
+		defer signal.Stop(reExecs)
+	}
+
+	// A nil channel never receives, leaving the lifetime unbounded.
+	var lifetimeOver <-chan time.Time
+	if a.maxLifetime > 0 {
+		lifetimeOver = a.after(a.maxLifetime)
+	}
+
+	for {
+		select {
+		case <-reloads:
+			a.reload(ctx)
+		case <-pauses:
+			a.togglePause()
+		case sig := <-forceExits:
+			a.log().Error("Force exit signal received! Exiting now.",
+				slog.String("signal", SignalName(sig)))
+			a.forceExit(ExitForced)
+		case <-a.restartChan():
+			cancelLoop(fmt.Errorf("%w: restarting", ErrShuttingDown))
+			a.log().Info("Restart requested! Restarting the app in process...")
+			// The new main loop must not run along with the old one.
+			if errs != nil && !a.awaitLoop(errs) {
+				res.loopErr = fmt.Errorf("main loop did not return within %s of the restart request", a.ShutdownTimeout)
+				a.log().Error("Main loop ignored the restart! Shutting down instead...",
+					slog.String("module", "app/app"),
+					slog.String("source", "app.Restart"),
+					slog.String("error", res.loopErr.Error()),
+				)
+				res.cause = Cause{Kind: CauseRestart, Err: res.loopErr}
+				_, res.shutdownErr = a.runShutdown(withShutdownCause(shutdownCtx, res.cause))
+				return res
+			}
+			a.endCycle(withShutdownCause(shutdownCtx, Cause{Kind: CauseRestart}))
+			if errs, cancelLoop, startupErr = a.startCycle(ctx, launch); startupErr != nil {
+				return a.startupFailed(shutdownCtx, startupErr)
+			}
+			a.log().Info("App restarted.")
+		case sig := <-reExecs:
+			cmd, err := prepareReExec(a.reExecFiles)
+			if err != nil {
+				a.log().Error("Re-exec signal received but the app cannot be re-executed! It keeps running.",
+					slog.String("module", "app/app"),
+					slog.String("source", "app.ReExec"),
+					slog.String("signal", SignalName(sig)),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+			res.cause = Cause{Kind: CauseSignal, Err: fmt.Errorf("received %s to re-exec", SignalName(sig))}
+			cancelLoop(fmt.Errorf("%w: %w", ErrShuttingDown, res.cause.Err))
+			a.log().Info("Re-exec signal received! Restarting the app.",
+				slog.String("signal", SignalName(sig)))
+			res.shutdownErr = a.reExec(withShutdownCause(shutdownCtx, res.cause), cmd)
+			return res
+		case sig := <-sigs:
+			if !bypassVetoes && a.shutdownVetoed(ctx) {
+				// The next signal must be able to stop the app anyway.
+				bypassVetoes = true
+				a.log().Warn("Graceful shutdown signal received but shutdown was vetoed! Send another one to shut down anyway.",
+					slog.String("signal", SignalName(sig)))
+				continue
+			}
+			res.cause = Cause{Kind: CauseSignal, Err: fmt.Errorf("received %s", SignalName(sig))}
+			cancelLoop(fmt.Errorf("%w: %w", ErrShuttingDown, res.cause.Err))
+			a.log().Info("Graceful shutdown signal received!"+a.graceNotice(),
+				slog.String("signal", SignalName(sig)))
+			res.shutdownErr = a.gracefulShutdown(withShutdownCause(shutdownCtx, res.cause), sigs)
+			return res
+		case <-lifetimeOver:
+			res.cause = Cause{Kind: CauseMaxLifetime, Err: fmt.Errorf("maximum lifetime of %s reached", a.maxLifetime)}
+			cancelLoop(fmt.Errorf("%w: %w", ErrShuttingDown, res.cause.Err))
+			a.log().Info("Maximum lifetime reached, terminating!"+a.graceNotice(),
+				slog.Duration("max_lifetime", a.maxLifetime))
+			res.shutdownErr = a.gracefulShutdown(withShutdownCause(shutdownCtx, res.cause), sigs)
+			return res
+		case reason := <-a.triggerChan():
+			res.cause = Cause{Kind: CauseProgrammatic, Err: fmt.Errorf("shutdown triggered: %s", reason)}
+			cancelLoop(fmt.Errorf("%w: %w", ErrShuttingDown, res.cause.Err))
+			a.log().Info("Shutdown triggered programmatically!"+a.graceNotice(),
+				slog.String("reason", reason))
+			res.shutdownErr = a.gracefulShutdown(withShutdownCause(shutdownCtx, res.cause), sigs)
+			return res
+		case <-ctx.Done():
+			// The main loop context is canceled along with ctx, with its cause.
+			a.log().Info("App context canceled!" + a.graceNotice())
+			res.cause = Cause{Kind: CauseContextCanceled, Err: context.Cause(ctx)}
+			res.shutdownErr = a.gracefulShutdown(withShutdownCause(shutdownCtx, res.cause), sigs)
+			return res
+		case res.loopErr = <-errs:
+			a.SetReady(false)
+			if res.loopErr == nil && a.requireRunningLoop {
+				res.loopErr = ErrLoopExitedUnexpectedly
+			}
+			if res.loopErr == nil {
+				a.log().Info("Main Loop finished by itself, initiating shutdown procedures...")
+			} else {
+				a.log().Error("Main Loop finished by itself with an error, initiating shutdown procedures...",
+					slog.String("error", res.loopErr.Error()))
+				// Panics were already reported when they were recovered.
+				if perr := (*PanicError)(nil); !errors.As(res.loopErr, &perr) {
+					a.reportError(ctx, res.loopErr)
+				}
+			}
+			res.cause = Cause{Kind: CauseMainLoopError, Err: res.loopErr}
+			_, res.shutdownErr = a.runShutdown(withShutdownCause(shutdownCtx, res.cause))
+			return res
+		}
 	}
-	if err == nil {
-		a.logger.Info("App gracefully terminated.")
+}
+
+// terminate logs how the app terminated, reports it to the WithOnTerminated
+// function and closes Done. With WithPanicOnShutdownError, it then panics
+// with the shutdown error, if any.
+func (a *App) terminate(res runResult) {
+	reason := slog.String("reason", res.cause.Kind.String())
+	if err := res.err(); err == nil {
+		a.log().Info("App gracefully terminated.", reason)
 	} else {
-		a.logger.Error("App terminated with error",
+		a.log().Error("App terminated with error",
+			reason,
 			slog.String("error", err.Error()))
 	}
+	a.flushLogger()
+	if a.onTerminated != nil {
+		a.onTerminated(res.err())
+	}
+	a.markDone()
+	if res.shutdownErr != nil && a.panicOnShutdownError {
+		panic(res.shutdownErr)
+	}
 }
 
-// Shutdown calls all shutdown methods, in order they were added.
-func (a *App) Shutdown(ctx context.Context) error {
-	if defaultApp == nil {
-		panic("default app not initialized")
+// startCycle runs the startup handlers and then launches the main loop with
+// launch, returning its result channel and the function canceling its context.
+func (a *App) startCycle(ctx context.Context, launch func(context.Context) <-chan error) (<-chan error, context.CancelCauseFunc, error) {
+	// The main loop can tell why it is canceled with context.Cause.
+	loopCtx, cancelLoop := context.WithCancelCause(ctx)
+	a.setState(StateStarting)
+	a.mu.Lock()
+	a.cycleStarted = true
+	a.mu.Unlock()
+	if err := a.runStartupHandlers(ctx); err != nil {
+		return nil, cancelLoop, err
 	}
-
-	for _, shutdownHandler := range a.shutdownHandlers {
-		err := shutdownHandler(ctx)
-		if err != nil {
-			a.logger.Error("error executing shutdown handler",
-				slog.String("module", "app/app"),
-				slog.String("source", "app.Shutdown"),
-				slog.String("error", err.Error()),
-			)
+	if a.controlSocket != "" {
+		if err := a.startControlSocket(); err != nil {
+			return nil, cancelLoop, err
 		}
 	}
 
-	return nil
+	a.setState(StateRunning)
+	a.SetReady(true)
+	if !a.quietStartupSummary {
+		a.logStartupSummary()
+	}
+	var errs <-chan error
+	if launch != nil {
+		errs = launch(loopCtx)
+	}
+	return errs, cancelLoop, nil
 }
 
-// RegisterShutdownHandler calls the RegisterShutdownHandler from the default app
-func (a *App) RegisterShutdownHandler(handler ShutdownHandler) {
-	if defaultApp == nil {
-		panic("default app not initialized")
+// logStartupSummary logs the configuration the app runs with.
+func (a *App) logStartupSummary() {
+	handlers := a.HandlerNames()
+	actions := a.signalActions()
+	signalNames := make([]string, 0, len(actions))
+	for sig, action := range actions {
+		signalNames = append(signalNames, SignalName(sig)+"="+action.String())
 	}
+	slices.Sort(signalNames)
+	a.log().Info("Application started.",
+		slog.Duration("grace_period", a.GracePeriod),
+		slog.Duration("shutdown_timeout", a.ShutdownTimeout),
+		slog.Int("shutdown_handlers", len(handlers)),
+		slog.Any("shutdown_handler_names", handlers),
+		slog.Any("signals", signalNames),
+	)
+}
 
-	a.shutdownHandlers = append(a.shutdownHandlers, handler)
+// startupFailed shuts the app down after a startup handler failed.
+func (a *App) startupFailed(ctx context.Context, err error) runResult {
+	a.log().Error("Startup failed, initiating shutdown procedures...",
+		slog.String("error", err.Error()))
+	res := runResult{startupErr: err, cause: Cause{Kind: CauseStartupError, Err: err}}
+	_, res.shutdownErr = a.runShutdown(withShutdownCause(ctx, res.cause))
+	return res
+}
+
+// awaitLoop waits for the canceled main loop to return, for at most
+// ShutdownTimeout, and reports whether it did.
+func (a *App) awaitLoop(errs <-chan error) bool {
+	if a.ShutdownTimeout <= 0 {
+		<-errs
+		return true
+	}
+	timer := time.NewTimer(a.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-errs:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// endCycle runs the drain and shutdown handlers registered once the app
+// started, by the startup handlers or the main loop, and then forgets them so
+// that the startup handlers can register them again. The handlers registered
+// before Run are kept for the final shutdown. Errors are only logged since the
+// app keeps running.
+func (a *App) endCycle(ctx context.Context) {
+	a.setState(StateShuttingDown)
+	a.SetReady(false)
+	if err := a.shutdown(ctx, true); err != nil {
+		a.log().Error("Shutdown handlers failed while restarting",
+			slog.String("error", err.Error()))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	perCycle := func(h namedShutdownHandler) bool { return h.perCycle }
+	a.shutdownHandlers = slices.DeleteFunc(a.shutdownHandlers, perCycle)
+	a.drainHandlers = slices.DeleteFunc(a.drainHandlers, perCycle)
+	a.shutdownStarted = false
+	a.shutdownFinished = false
+}
+
+// callMainLoop runs the main loop, converting a panic into an error.
+func (a *App) callMainLoop(ctx context.Context, mainLoop MainLoopFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := newPanicError(r)
+			a.log().Error("Application main loop panicked!",
+				slog.String("error", perr.Error()),
+				slog.String("stack", string(perr.Stack)))
+			a.reportError(ctx, perr)
+			err = perr
+		}
+	}()
+	return mainLoop(ctx)
+}
+
+// baseContext returns the context the app was created with.
+func (a *App) baseContext() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
 }
@@ -0,0 +1,178 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestPanicOnShutdownErrorReportsTheError(t *testing.T) {
+	errClose := errors.New("close failed")
+	var terminatedErr error
+	a := apptest.NewTestApp(t,
+		app.WithPanicOnShutdownError(),
+		app.WithOnTerminated(func(err error) { terminatedErr = err }),
+	)
+	a.RegisterShutdownHandlerOpts(func(context.Context) error {
+		return errClose
+	}, app.HandlerOpts{Name: "db", Critical: true})
+
+	defer func() {
+		r := recover()
+		err, ok := r.(error)
+		if !ok || !errors.Is(err, errClose) {
+			t.Errorf("RunE panicked with %v, want the shutdown error", r)
+		}
+		if !errors.Is(terminatedErr, errClose) {
+			t.Errorf("WithOnTerminated got %v, want the shutdown error", terminatedErr)
+		}
+		select {
+		case <-a.Done():
+		default:
+			t.Error("Done() is not closed after the panic")
+		}
+	}()
+	a.RunE(context.Background(), func(context.Context) error { return nil })
+	t.Error("RunE returned instead of panicking")
+}
+
+func TestRunNilMainLoopTerminates(t *testing.T) {
+	var terminatedErr error
+	a := apptest.NewTestApp(t, app.WithOnTerminated(func(err error) { terminatedErr = err }))
+
+	if err := a.RunE(context.Background(), nil); !errors.Is(err, app.ErrNilMainLoop) {
+		t.Errorf("RunE(nil) = %v, want ErrNilMainLoop", err)
+	}
+	if !errors.Is(terminatedErr, app.ErrNilMainLoop) {
+		t.Errorf("WithOnTerminated got %v, want ErrNilMainLoop", terminatedErr)
+	}
+	select {
+	case <-a.Done():
+	default:
+		t.Error("Done() is not closed after RunE(nil)")
+	}
+}
+
+func TestMainLoopContextCanceledBeforeGracePeriod(t *testing.T) {
+	clock := apptest.NewFakeClock(time.Now())
+	a := apptest.NewTestApp(t, app.WithClock(clock), app.WithGracePeriod(time.Hour))
+	observed := make(chan struct{})
+	go func() {
+		select {
+		case <-observed:
+		case <-time.After(5 * time.Second):
+			t.Error("the main loop did not observe the shutdown during the grace period")
+		}
+		// Ends the grace period, which waits on the fake clock.
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Hour)
+	}()
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		a.TriggerShutdown("test")
+		<-ctx.Done()
+		close(observed)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+}
+
+func TestMainLoopPanicShutsDown(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	shutdown := false
+	a.RegisterShutdownHandler(func(context.Context) error {
+		shutdown = true
+		return nil
+	})
+
+	err := a.RunE(context.Background(), func(context.Context) error {
+		panic("boom")
+	})
+	var perr *app.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("RunE() = %v, want a *PanicError", err)
+	}
+	if perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Errorf("PanicError = {Value: %v, Stack: %d bytes}, want the panic value and its stack", perr.Value, len(perr.Stack))
+	}
+	if !shutdown {
+		t.Error("the shutdown handler did not run after the main loop panicked")
+	}
+}
+
+func TestMainLoopReturningNil(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	shutdown := false
+	a.RegisterShutdownHandler(func(context.Context) error {
+		shutdown = true
+		return nil
+	})
+
+	if code := a.Run(func(context.Context) error { return nil }); code != app.ExitOK {
+		t.Errorf("Run() = %d, want ExitOK", code)
+	}
+	if !shutdown {
+		t.Error("the shutdown handler did not run after the main loop returned")
+	}
+}
+
+func TestRunEReturnsLoopAndShutdownErrors(t *testing.T) {
+	errLoop := errors.New("loop failed")
+	errClose := errors.New("close failed")
+	a := apptest.NewTestApp(t)
+	a.RegisterShutdownHandler(func(context.Context) error { return errClose })
+
+	err := a.RunE(context.Background(), func(context.Context) error { return errLoop })
+	if !errors.Is(err, errLoop) || !errors.Is(err, errClose) {
+		t.Errorf("RunE() = %v, want both the main loop and the shutdown handler errors", err)
+	}
+}
+
+func TestRunEShutsDownWhenContextCanceled(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	shutdown := false
+	a.RegisterShutdownHandler(func(context.Context) error {
+		shutdown = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := a.RunE(ctx, func(loopCtx context.Context) error {
+		cancel()
+		<-loopCtx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if !shutdown {
+		t.Error("the shutdown handler did not run after the context was canceled")
+	}
+}
+
+func TestMainLoopContextCause(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	// RunE does not wait for the main loop once it is canceled.
+	causes := make(chan error, 1)
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		a.TriggerShutdown("maintenance")
+		<-ctx.Done()
+		causes <- context.Cause(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if cause := <-causes; !errors.Is(cause, app.ErrShuttingDown) || !strings.Contains(cause.Error(), "maintenance") {
+		t.Errorf("context.Cause() = %v, want ErrShuttingDown with the shutdown reason", cause)
+	}
+}
@@ -0,0 +1,84 @@
+package apptest
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+)
+
+// ShutdownTimeout is the shutdown timeout of the apps created by NewTestApp.
+const ShutdownTimeout = time.Second
+
+// NewTestApp creates an app suited for tests: it logs through tb.Log, has no
+// grace period, a short shutdown timeout and no shutdown signal, so it must
+// be stopped with TriggerShutdown or by canceling its context. SIGHUP still
+// calls its reload handlers while it runs.
+// The given options are applied on top of these defaults.
+func NewTestApp(tb testing.TB, opts ...app.Option) *app.App {
+	tb.Helper()
+	defaults := []app.Option{
+		app.WithLogOutput(newTBWriter(tb)),
+		app.WithTextLogs(),
+		app.WithGracePeriod(0),
+		app.WithShutdownTimeout(ShutdownTimeout),
+		app.WithSignals(),
+	}
+	return app.NewApp(context.Background(), append(defaults, opts...)...)
+}
+
+// Shutdown calls a.Shutdown synchronously and fails the test if it returns
+// an error, meaning some critical handler failed or did not get to run
+// before the shutdown timeout.
+func Shutdown(tb testing.TB, a *app.App) {
+	tb.Helper()
+	if err := a.Shutdown(context.Background()); err != nil {
+		tb.Fatalf("shutdown failed: %v", err)
+	}
+}
+
+// ShutdownAndAssertRan calls Shutdown and then fails the test unless the
+// shutdown handlers named names all ran to completion, as told by
+// a.ShutdownTimings.
+func ShutdownAndAssertRan(tb testing.TB, a *app.App, names ...string) {
+	tb.Helper()
+	Shutdown(tb, a)
+	timings := a.ShutdownTimings()
+	for _, name := range names {
+		if _, ok := timings[name]; !ok {
+			tb.Errorf("shutdown handler %q did not run, registered handlers: %v", name, a.ShutdownPlan())
+		}
+	}
+}
+
+// tbWriter writes every log line with tb.Log, until the test completes:
+// testing panics when a goroutine logs after that, and the app goroutines
+// can outlive the test.
+type tbWriter struct {
+	tb     testing.TB
+	mu     sync.Mutex
+	closed bool
+}
+
+func newTBWriter(tb testing.TB) *tbWriter {
+	w := &tbWriter{tb: tb}
+	tb.Cleanup(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.closed = true
+	})
+	return w
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.tb.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.tb.Log(string(bytes.TrimRight(p, "\n")))
+	}
+	return len(p), nil
+}
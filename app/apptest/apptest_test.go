@@ -0,0 +1,26 @@
+package apptest_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestShutdownAndAssertRan(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	a.RegisterNamedShutdownHandler("db", func(context.Context) error { return nil })
+	a.RegisterNamedShutdownHandler("http", func(context.Context) error { return nil })
+
+	apptest.ShutdownAndAssertRan(t, a, "db", "http")
+}
+
+func TestLogAfterTestCompleted(t *testing.T) {
+	var logger *slog.Logger
+	t.Run("app", func(t *testing.T) {
+		logger = apptest.NewTestApp(t).Logger()
+	})
+	// Would panic if it reached the completed test.
+	logger.Info("logged by a goroutine outliving the test")
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// AppBuilder configures an app with chainable methods, as an alternative to
+// passing options to NewApp. Every method funnels through the same options.
+type AppBuilder struct {
+	opts     []Option
+	handlers []ShutdownHandler
+}
+
+// Builder returns a builder for an app configured with the default values.
+func Builder() *AppBuilder {
+	return &AppBuilder{}
+}
+
+// Option adds any option to the app, for the settings without a dedicated
+// builder method.
+func (b *AppBuilder) Option(opt Option) *AppBuilder {
+	b.opts = append(b.opts, opt)
+	return b
+}
+
+// GracePeriod sets the grace period, see WithGracePeriod.
+func (b *AppBuilder) GracePeriod(d time.Duration) *AppBuilder {
+	return b.Option(WithGracePeriod(d))
+}
+
+// ShutdownTimeout sets the shutdown timeout, see WithShutdownTimeout.
+func (b *AppBuilder) ShutdownTimeout(d time.Duration) *AppBuilder {
+	return b.Option(WithShutdownTimeout(d))
+}
+
+// Logger sets the logger, see WithLogger.
+func (b *AppBuilder) Logger(logger *slog.Logger) *AppBuilder {
+	return b.Option(WithLogger(logger))
+}
+
+// Signals sets the shutdown signals, see WithSignals.
+func (b *AppBuilder) Signals(sigs ...os.Signal) *AppBuilder {
+	return b.Option(WithSignals(sigs...))
+}
+
+// ShutdownHandler adds a shutdown handler registered once the app is built,
+// in the order the handlers were added.
+func (b *AppBuilder) ShutdownHandler(handler ShutdownHandler) *AppBuilder {
+	b.handlers = append(b.handlers, handler)
+	return b
+}
+
+// Build creates the app, see NewApp.
+func (b *AppBuilder) Build(ctx context.Context) *App {
+	a := NewApp(ctx, b.opts...)
+	b.register(a)
+	return a
+}
+
+// BuildDefault creates the default app, see NewDefaultApp, and returns it.
+func (b *AppBuilder) BuildDefault(ctx context.Context) *App {
+	NewDefaultApp(ctx, b.opts...)
+	b.register(defaultApp)
+	return defaultApp
+}
+
+func (b *AppBuilder) register(a *App) {
+	for _, handler := range b.handlers {
+		a.RegisterShutdownHandler(handler)
+	}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrShuttingDown is the cause, as returned by context.Cause, of the
+// cancellation of the main loop context. The actual cause wraps it with the
+// reason of the shutdown, such as the signal received or the reason given to
+// TriggerShutdown. When the app context is canceled, the main loop context
+// has the cause of the app context instead.
+var ErrShuttingDown = errors.New("app shutting down")
+
+// CauseKind tells why the app is shutting down.
+type CauseKind int
+
+const (
+	// CauseUnknown is reported when the shutdown was not initiated by the
+	// app lifecycle, for instance when Shutdown is called directly.
+	CauseUnknown CauseKind = iota
+	// CauseSignal means a shutdown signal was received. The error names it.
+	CauseSignal
+	// CauseMainLoopError means the main loop returned by itself.
+	// The error is nil if the main loop returned nil.
+	CauseMainLoopError
+	// CauseProgrammatic means TriggerShutdown was called.
+	CauseProgrammatic
+	// CauseContextCanceled means the context the app was created with was canceled.
+	CauseContextCanceled
+	// CauseStartupError means a startup handler failed.
+	CauseStartupError
+	// CauseMaxLifetime means the app ran for its maximum lifetime, see
+	// WithMaxLifetime.
+	CauseMaxLifetime
+	// CauseRestart means the shutdown handlers run because Restart was
+	// called: the app is not terminating.
+	CauseRestart
+)
+
+func (k CauseKind) String() string {
+	switch k {
+	case CauseSignal:
+		return "signal"
+	case CauseMainLoopError:
+		return "main_loop_error"
+	case CauseProgrammatic:
+		return "programmatic"
+	case CauseContextCanceled:
+		return "context_canceled"
+	case CauseStartupError:
+		return "startup_error"
+	case CauseMaxLifetime:
+		return "max_lifetime"
+	case CauseRestart:
+		return "restart"
+	default:
+		return "unknown"
+	}
+}
+
+// Cause describes why the app is shutting down.
+type Cause struct {
+	Kind CauseKind
+	// Err is the underlying error, if any.
+	Err error
+}
+
+type shutdownCauseKey struct{}
+
+// ShutdownCause returns the cause of the shutdown attached to the context
+// given to shutdown handlers. Its Kind is CauseUnknown when there is none.
+func ShutdownCause(ctx context.Context) Cause {
+	cause, _ := ctx.Value(shutdownCauseKey{}).(Cause)
+	return cause
+}
+
+func withShutdownCause(ctx context.Context, cause Cause) context.Context {
+	return context.WithValue(ctx, shutdownCauseKey{}, cause)
+}
@@ -0,0 +1,46 @@
+package app
+
+import "time"
+
+// Clock tells the time and waits for durations to elapse.
+// It lets tests drive the grace period and shutdown durations deterministically,
+// see apptest.FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current
+	// time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// now returns the current time according to the app clock.
+func (a *App) now() time.Time {
+	if a.clock == nil {
+		return time.Now()
+	}
+	return a.clock.Now()
+}
+
+// since returns the time elapsed since t according to the app clock.
+func (a *App) since(t time.Time) time.Duration {
+	return a.now().Sub(t)
+}
+
+// after waits for the duration to elapse according to the app clock.
+func (a *App) after(d time.Duration) <-chan time.Time {
+	if a.clock == nil {
+		return time.After(d)
+	}
+	return a.clock.After(d)
+}
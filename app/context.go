@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Context returns a context canceled as soon as the app starts shutting
+// down, before the grace period, so that subsystems deriving their contexts
+// from it all observe the shutdown at once without registering a handler.
+// It is available, and not canceled, before the app runs. Its cause, as
+// returned by context.Cause, wraps ErrShuttingDown. Restart does not cancel it.
+func (a *App) Context() context.Context {
+	ctx, _ := a.appContext()
+	return ctx
+}
+
+// appContext lazily creates the context returned by Context. It carries the
+// values of the context the app was created with, but not its cancellation.
+func (a *App) appContext() (context.Context, context.CancelCauseFunc) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.appCtx == nil {
+		a.appCtx, a.cancelAppCtx = context.WithCancelCause(context.WithoutCancel(a.baseContext()))
+	}
+	return a.appCtx, a.cancelAppCtx
+}
+
+// cancelAppContext cancels the context returned by Context with the shutdown
+// cause attached to ctx.
+func (a *App) cancelAppContext(ctx context.Context) {
+	_, cancel := a.appContext()
+	if cause := ShutdownCause(ctx); cause.Err != nil {
+		cancel(fmt.Errorf("%w: %w", ErrShuttingDown, cause.Err))
+		return
+	}
+	cancel(ErrShuttingDown)
+}
+
+// shutdownBudgetKey is the context key of the shutdown budget.
+type shutdownBudgetKey struct{}
+
+// shutdownBudget returns the longest time between the main loop context
+// being canceled and the shutdown deadline: the grace period, with its
+// jitter, plus ShutdownTimeout. It is zero when ShutdownTimeout is zero,
+// since the shutdown is then unbounded.
+func (a *App) shutdownBudget() time.Duration {
+	if a.ShutdownTimeout <= 0 {
+		return 0
+	}
+	return max(a.GracePeriod, 0) + max(a.graceJitter, 0) + a.ShutdownTimeout
+}
+
+// withShutdownBudget attaches the shutdown budget to the main loop context,
+// for helpers such as WorkerPool to stay within it.
+func withShutdownBudget(ctx context.Context, budget time.Duration) context.Context {
+	if budget <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, shutdownBudgetKey{}, budget)
+}
+
+// shutdownBudgetFromContext returns the shutdown budget attached to ctx, if any.
+func shutdownBudgetFromContext(ctx context.Context) (time.Duration, bool) {
+	budget, ok := ctx.Value(shutdownBudgetKey{}).(time.Duration)
+	return budget, ok
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+)
+
+// startControlSocket listens on the control socket set with
+// WithControlSocket and registers the shutdown handler closing it.
+func (a *App) startControlSocket() error {
+	// A socket file left by a process that crashed would prevent listening.
+	if info, err := os.Lstat(a.controlSocket); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		_ = os.Remove(a.controlSocket)
+	}
+	ln, err := net.Listen("unix", a.controlSocket)
+	if err != nil {
+		return fmt.Errorf("control socket: %w", err)
+	}
+
+	go a.serveControlSocket(ln)
+	// Closing the listener removes the socket file.
+	a.RegisterNamedShutdownHandler("control-socket", func(context.Context) error {
+		return ln.Close()
+	})
+	return nil
+}
+
+// serveControlSocket accepts the control connections until ln is closed.
+func (a *App) serveControlSocket(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				a.log().Error("control socket failed",
+					slog.String("module", "app/control"),
+					slog.String("source", "app.WithControlSocket"),
+					slog.String("error", err.Error()),
+				)
+			}
+			return
+		}
+		go a.handleControlConn(conn)
+	}
+}
+
+// handleControlConn answers the commands of a control connection, one per
+// line, until the client closes it.
+func (a *App) handleControlConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var reply string
+		switch command := strings.TrimSpace(scanner.Text()); command {
+		case "shutdown":
+			a.TriggerShutdown("control socket")
+			reply = "ok"
+		case "status":
+			reply = a.State().String()
+		default:
+			reply = fmt.Sprintf("unknown command %q", command)
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// ShutdownDeadline returns the time by which the shutdown handler given ctx
+// must be done, the earliest of the ShutdownTimeout and the handler's own
+// timeout. It returns false when no timeout is set.
+func ShutdownDeadline(ctx context.Context) (time.Time, bool) {
+	return ctx.Deadline()
+}
+
+// TimeRemaining returns how long the shutdown handler given ctx has left,
+// so that it can budget its own sub-operations, see ShutdownDeadline.
+// It returns zero when no timeout is set, as well as once the deadline
+// passed.
+func TimeRemaining(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return max(time.Until(deadline), 0)
+}
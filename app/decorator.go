@@ -0,0 +1,30 @@
+package app
+
+import "context"
+
+// ContextDecorator derives the context given to the app handlers, typically
+// to attach values such as a trace id or a shared resource handle.
+type ContextDecorator func(context.Context) context.Context
+
+// RegisterContextDecorator adds a decorator applied to the app context when
+// the app runs, before it is given to the startup handlers, the main loop,
+// the reload handlers and the shutdown handlers. Decorators are applied in
+// registration order, each one wrapping the context returned by the
+// previous one. Contexts given to Shutdown directly are not decorated.
+func (a *App) RegisterContextDecorator(decorator ContextDecorator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.contextDecorators = append(a.contextDecorators, decorator)
+}
+
+// decorateContext applies the registered decorators to ctx.
+func (a *App) decorateContext(ctx context.Context) context.Context {
+	a.mu.Lock()
+	decorators := append([]ContextDecorator(nil), a.contextDecorators...)
+	a.mu.Unlock()
+
+	for _, decorate := range decorators {
+		ctx = decorate(ctx)
+	}
+	return ctx
+}
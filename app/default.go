@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// mustDefaultApp returns the default app, panicking if NewDefaultApp was not called.
+func mustDefaultApp() *App {
+	if defaultApp == nil {
+		panic("default app not initialized: call app.NewDefaultApp first")
+	}
+	return defaultApp
+}
+
+// RunAndWait calls RunAndWait on the default app.
+func RunAndWait(mainLoop MainLoopFunc) {
+	mustDefaultApp().RunAndWait(mainLoop)
+}
+
+// Shutdown calls Shutdown on the default app.
+func Shutdown(ctx context.Context) error {
+	return mustDefaultApp().Shutdown(ctx)
+}
+
+// RegisterShutdownHandler calls RegisterShutdownHandler on the default app.
+func RegisterShutdownHandler(handler ShutdownHandler) HandlerID {
+	return mustDefaultApp().RegisterShutdownHandler(handler)
+}
+
+// RegisterNamedShutdownHandler calls RegisterNamedShutdownHandler on the default app.
+func RegisterNamedShutdownHandler(name string, handler ShutdownHandler) HandlerID {
+	return mustDefaultApp().RegisterNamedShutdownHandler(name, handler)
+}
+
+// RegisterShutdownHandlerWithTimeout calls RegisterShutdownHandlerWithTimeout on the default app.
+func RegisterShutdownHandlerWithTimeout(handler ShutdownHandler, timeout time.Duration) HandlerID {
+	return mustDefaultApp().RegisterShutdownHandlerWithTimeout(handler, timeout)
+}
+
+// DeregisterShutdownHandler calls DeregisterShutdownHandler on the default app.
+func DeregisterShutdownHandler(id HandlerID) bool {
+	return mustDefaultApp().DeregisterShutdownHandler(id)
+}
+
+// Run calls Run on the default app.
+func Run(mainLoop MainLoopFunc) int {
+	return mustDefaultApp().Run(mainLoop)
+}
+
+// RegisterStartupHandler calls RegisterStartupHandler on the default app.
+func RegisterStartupHandler(handler StartupHandler) {
+	mustDefaultApp().RegisterStartupHandler(handler)
+}
+
+// RegisterReloadHandler calls RegisterReloadHandler on the default app.
+func RegisterReloadHandler(handler ReloadHandler) {
+	mustDefaultApp().RegisterReloadHandler(handler)
+}
+
+// TriggerShutdown calls TriggerShutdown on the default app.
+func TriggerShutdown(reason string) {
+	mustDefaultApp().TriggerShutdown(reason)
+}
+
+// Wait calls Wait on the default app.
+func Wait() {
+	mustDefaultApp().Wait()
+}
+
+// RegisterErrorReporter calls RegisterErrorReporter on the default app.
+func RegisterErrorReporter(reporter ErrorReporter) {
+	mustDefaultApp().RegisterErrorReporter(reporter)
+}
+
+// RegisterShutdownHandlerWithPriority calls RegisterShutdownHandlerWithPriority on the default app.
+func RegisterShutdownHandlerWithPriority(priority int, handler ShutdownHandler) HandlerID {
+	return mustDefaultApp().RegisterShutdownHandlerWithPriority(priority, handler)
+}
+
+// RunE calls RunE on the default app.
+func RunE(ctx context.Context, mainLoop MainLoopFunc) error {
+	return mustDefaultApp().RunE(ctx, mainLoop)
+}
+
+// RegisterShutdownHandlerOpts calls RegisterShutdownHandlerOpts on the default app.
+func RegisterShutdownHandlerOpts(handler ShutdownHandler, opts HandlerOpts) HandlerID {
+	return mustDefaultApp().RegisterShutdownHandlerOpts(handler, opts)
+}
+
+// RegisterRetryingShutdownHandler calls RegisterRetryingShutdownHandler on the default app.
+func RegisterRetryingShutdownHandler(handler ShutdownHandler, attempts int, backoff time.Duration) HandlerID {
+	return mustDefaultApp().RegisterRetryingShutdownHandler(handler, attempts, backoff)
+}
+
+// RunAll calls RunAll on the default app.
+func RunAll(loops ...MainLoopFunc) {
+	mustDefaultApp().RunAll(loops...)
+}
+
+// Group calls Group on the default app.
+func Group() *RunGroup {
+	return mustDefaultApp().Group()
+}
+
+// RunGroupAndWait calls RunGroupAndWait on the default app.
+func RunGroupAndWait() int {
+	return mustDefaultApp().RunGroupAndWait()
+}
+
+// RegisterShutdownVeto calls RegisterShutdownVeto on the default app.
+func RegisterShutdownVeto(veto ShutdownVeto) {
+	mustDefaultApp().RegisterShutdownVeto(veto)
+}
+
+// RegisterShutdownHandlerDep calls RegisterShutdownHandlerDep on the default app.
+func RegisterShutdownHandlerDep(name string, dependsOn []string, handler ShutdownHandler) (HandlerID, error) {
+	return mustDefaultApp().RegisterShutdownHandlerDep(name, dependsOn, handler)
+}
+
+// RegisterContextDecorator calls RegisterContextDecorator on the default app.
+func RegisterContextDecorator(decorator ContextDecorator) {
+	mustDefaultApp().RegisterContextDecorator(decorator)
+}
+
+// RegisterCleanup calls RegisterCleanup on the default app.
+func RegisterCleanup(cleanup func() error) HandlerID {
+	return mustDefaultApp().RegisterCleanup(cleanup)
+}
+
+// RegisterCloser calls RegisterCloser on the default app.
+func RegisterCloser(c io.Closer) HandlerID {
+	return mustDefaultApp().RegisterCloser(c)
+}
+
+// RegisterConditionalShutdownHandler calls RegisterConditionalShutdownHandler on the default app.
+func RegisterConditionalShutdownHandler(cond func() bool, handler ShutdownHandler) HandlerID {
+	return mustDefaultApp().RegisterConditionalShutdownHandler(cond, handler)
+}
+
+// RegisterDrainHandler calls RegisterDrainHandler on the default app.
+func RegisterDrainHandler(handler ShutdownHandler) {
+	mustDefaultApp().RegisterDrainHandler(handler)
+}
+
+// RegisterStartupHandlerWithRetry calls RegisterStartupHandlerWithRetry on the default app.
+func RegisterStartupHandlerWithRetry(handler StartupHandler, attempts int, backoff time.Duration) {
+	mustDefaultApp().RegisterStartupHandlerWithRetry(handler, attempts, backoff)
+}
+
+// RegisterHealthCheck calls RegisterHealthCheck on the default app.
+func RegisterHealthCheck(name string, check HealthCheck) {
+	mustDefaultApp().RegisterHealthCheck(name, check)
+}
+
+// TriggerShutdownAndWait calls TriggerShutdownAndWait on the default app.
+func TriggerShutdownAndWait(ctx context.Context, reason string) error {
+	return mustDefaultApp().TriggerShutdownAndWait(ctx, reason)
+}
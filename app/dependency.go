@@ -0,0 +1,84 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// RegisterShutdownHandlerDep adds a handler named name to be called during
+// Shutdown, before the handlers it depends on: an HTTP server depending on
+// a database is stopped before the database is closed. Dependencies refer
+// to handler names and may be registered later; unknown ones are ignored.
+// Dependencies take precedence over priorities and ShutdownOrder, which
+// still order the handlers that do not depend on each other. They are
+// ignored with ConcurrentShutdown.
+//
+// An error is returned, along with the zero HandlerID, and the handler is
+// not registered, when name is empty or the dependency would create a cycle,
+// including when the handler depends on itself.
+func (a *App) RegisterShutdownHandlerDep(name string, dependsOn []string, handler ShutdownHandler) (HandlerID, error) {
+	if name == "" {
+		return 0, fmt.Errorf("shutdown handler with dependencies must be named")
+	}
+
+	h := namedShutdownHandler{
+		name:      name,
+		fn:        handler,
+		dependsOn: slices.Clone(dependsOn),
+	}
+	a.mu.Lock()
+	if _, err := dependencyOrder(append(slices.Clone(a.shutdownHandlers), h)); err != nil {
+		a.mu.Unlock()
+		return 0, err
+	}
+	h, late := a.addShutdownHandlerLocked(h)
+	a.mu.Unlock()
+	if late {
+		a.runLateShutdownHandler(h)
+	}
+	return h.id, nil
+}
+
+// orderByDependencies reorders handlers so that every handler runs before
+// the handlers it depends on, keeping the given order otherwise.
+// Should a cycle be found anyway, it is logged and handlers is returned as is.
+func (a *App) orderByDependencies(handlers []namedShutdownHandler) []namedShutdownHandler {
+	ordered, err := dependencyOrder(handlers)
+	if err != nil {
+		a.log().Error("ignoring shutdown handler dependencies",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.Shutdown"),
+			slog.String("error", err.Error()),
+		)
+		return handlers
+	}
+	return ordered
+}
+
+// dependencyOrder sorts handlers so that no handler comes after one it
+// depends on. It repeatedly picks the first remaining handler that no other
+// remaining handler depends on, so that the sort is stable.
+func dependencyOrder(handlers []namedShutdownHandler) ([]namedShutdownHandler, error) {
+	remaining := slices.Clone(handlers)
+	ordered := make([]namedShutdownHandler, 0, len(handlers))
+	for len(remaining) > 0 {
+		next := slices.IndexFunc(remaining, func(h namedShutdownHandler) bool {
+			return !slices.ContainsFunc(remaining, func(other namedShutdownHandler) bool {
+				// A handler depending on itself is a cycle of its own.
+				return slices.Contains(other.dependsOn, h.name)
+			})
+		})
+		if next < 0 {
+			names := make([]string, len(remaining))
+			for i, h := range remaining {
+				names[i] = h.name
+			}
+			return nil, fmt.Errorf("shutdown handler dependency cycle between %s", strings.Join(names, ", "))
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return ordered, nil
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// RegisterDrainHandler adds a handler called during the first phase of
+// Shutdown, to stop accepting new work: closing listeners, leaving a
+// consumer group... Drain handlers run in registration order. Once they all
+// returned, Shutdown waits for the work tracked with TrackInflight to
+// complete, or for the shutdown deadline, before calling the shutdown
+// handlers, which finish and close what is left.
+// Drain handler errors are part of the error returned by Shutdown.
+func (a *App) RegisterDrainHandler(handler ShutdownHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.drainHandlers = append(a.drainHandlers, namedShutdownHandler{fn: handler, perCycle: a.cycleStarted})
+}
+
+// drain runs the drain phase of the shutdown: it calls the drain handlers,
+// only the ones registered once the app started when cycleOnly is set, and
+// waits for the in-flight work to complete.
+func (a *App) drain(ctx context.Context, cycleOnly bool) error {
+	a.mu.Lock()
+	handlers := append([]namedShutdownHandler(nil), a.drainHandlers...)
+	a.mu.Unlock()
+	if cycleOnly {
+		handlers = slices.DeleteFunc(handlers, func(h namedShutdownHandler) bool { return !h.perCycle })
+	}
+
+	var errs []error
+	for i, h := range handlers {
+		h.name = fmt.Sprintf("drain-%d", i)
+		a.log().Info("executing drain handler",
+			slog.String("handler", h.name),
+		)
+		// Like the shutdown handlers, a panicking drain handler does not
+		// prevent the next ones from running.
+		if err := a.watchCancellation(ctx, h); err != nil {
+			a.log().Error("error executing drain handler",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.String("handler", h.name),
+				slog.String("error", err.Error()),
+			)
+			a.reportError(ctx, err)
+			errs = append(errs, HandlerError{Name: h.name, Err: err})
+		}
+	}
+
+	if idle := a.idleChan(); idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			a.log().Warn("in-flight work did not complete, running shutdown handlers anyway",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.Int("inflight", a.Inflight()),
+			)
+		}
+	}
+	if len(handlers) > 0 {
+		a.log().Info("drain phase finished",
+			slog.Int("handlers", len(handlers)),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPDrainMiddleware returns a middleware tracking every request served by
+// the wrapped handler with TrackInflight, so that the grace period ends as
+// soon as the last in-flight request completed. Together with
+// HTTPServerShutdownHandler, it drains HTTP traffic on shutdown:
+//
+//	srv := &http.Server{Addr: ":8080", Handler: app.HTTPDrainMiddleware(a)(mux)}
+//	a.RegisterNamedShutdownHandler("http", app.HTTPServerShutdownHandler(srv))
+func HTTPDrainMiddleware(a *App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := a.TrackInflight()
+			defer done()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HTTPServerShutdownHandler returns a shutdown handler gracefully stopping
+// srv: it stops accepting connections and waits for the active requests to
+// complete, for as long as the shutdown context allows.
+func HTTPServerShutdownHandler(srv *http.Server) ShutdownHandler {
+	return func(ctx context.Context) error {
+		return srv.Shutdown(ctx)
+	}
+}
@@ -0,0 +1,104 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestDrainPhaseRunsFirst(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+	done := a.TrackInflight()
+	a.RegisterShutdownHandler(func(context.Context) error {
+		record("shutdown")
+		return nil
+	})
+	a.RegisterDrainHandler(func(context.Context) error {
+		record("drain")
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			record("in-flight work done")
+			done()
+		}()
+		return nil
+	})
+
+	apptest.Shutdown(t, a)
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"drain", "in-flight work done", "shutdown"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestPanickingDrainHandlerDoesNotStopTheShutdown(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var calls []string
+	a.RegisterDrainHandler(func(context.Context) error {
+		panic("boom")
+	})
+	a.RegisterDrainHandler(func(context.Context) error {
+		calls = append(calls, "drain")
+		return nil
+	})
+	a.RegisterShutdownHandler(func(context.Context) error {
+		calls = append(calls, "shutdown")
+		return nil
+	})
+
+	err := a.Shutdown(context.Background())
+	var perr *app.PanicError
+	if !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("Shutdown() = %v, want the recovered *PanicError", err)
+	}
+	if want := []string{"drain", "shutdown"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func ExampleHTTPDrainMiddleware() {
+	a := app.NewApp(context.Background(), app.WithLogOutput(io.Discard))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "requests in flight: %d", a.Inflight())
+	})
+	srv := httptest.NewServer(app.HTTPDrainMiddleware(a)(mux))
+	defer srv.Close()
+	a.RegisterNamedShutdownHandler("http", app.HTTPServerShutdownHandler(srv.Config))
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		panic(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Println(string(body))
+
+	if err := a.Shutdown(context.Background()); err != nil {
+		panic(err)
+	}
+	fmt.Println("requests in flight after shutdown:", a.Inflight())
+	_, err = http.Get(srv.URL)
+	fmt.Println("request refused after shutdown:", err != nil)
+	// Output:
+	// requests in flight: 1
+	// requests in flight after shutdown: 0
+	// request refused after shutdown: true
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Environment variables read by WithEnvDefaults, parsed with time.ParseDuration.
+const (
+	GracePeriodEnv     = "APP_GRACE_PERIOD"
+	ShutdownTimeoutEnv = "APP_SHUTDOWN_TIMEOUT"
+)
+
+// applyEnvDefaults sets the grace period and the shutdown timeout from the
+// environment, unless they were given with options. It must be called once
+// the logger is set, to report invalid values.
+func (a *App) applyEnvDefaults() {
+	if !a.envDefaults {
+		return
+	}
+	if !a.gracePeriodSet {
+		a.GracePeriod = a.durationFromEnv(GracePeriodEnv, a.GracePeriod)
+	}
+	if !a.shutdownTimeoutSet {
+		a.ShutdownTimeout = a.durationFromEnv(ShutdownTimeoutEnv, a.ShutdownTimeout)
+	}
+}
+
+// durationFromEnv parses the duration in the environment variable name,
+// returning fallback when it is unset or invalid.
+func (a *App) durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		a.log().Warn("ignoring invalid duration in environment variable",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.NewApp"),
+			slog.String("variable", name),
+			slog.String("value", value),
+			slog.Duration("default", fallback),
+		)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HandlerError is the failure of a single drain or shutdown handler.
+type HandlerError struct {
+	// Name is the name of the handler.
+	Name string
+	Err  error
+}
+
+func (e HandlerError) Error() string {
+	return fmt.Sprintf("shutdown handler %q: %v", e.Name, e.Err)
+}
+
+func (e HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// ShutdownError is the error returned by Shutdown. It tells which handlers
+// failed, and supports errors.Is and errors.As on their errors.
+type ShutdownError struct {
+	// Handlers are the failures of the critical handlers, in the order they
+	// happened.
+	Handlers []HandlerError
+	// Err holds the failures not tied to a handler, such as the shutdown
+	// timeout. It is nil if there are none.
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	msgs := make([]string, 0, len(e.Handlers)+1)
+	for _, h := range e.Handlers {
+		msgs = append(msgs, h.Error())
+	}
+	if e.Err != nil {
+		msgs = append(msgs, e.Err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the handler errors, as HandlerError values, followed by Err.
+func (e *ShutdownError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Handlers)+1)
+	for _, h := range e.Handlers {
+		errs = append(errs, h)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// newShutdownError sorts the errors joined during the shutdown into a
+// ShutdownError. It returns a nil error, and not a nil *ShutdownError, when
+// there are none.
+func newShutdownError(err error) error {
+	if err == nil {
+		return nil
+	}
+	se := &ShutdownError{}
+	var others []error
+	var collect func(error)
+	collect = func(err error) {
+		switch err := err.(type) {
+		case HandlerError:
+			se.Handlers = append(se.Handlers, err)
+		case interface{ Unwrap() []error }:
+			// Only the shutdown joins errors outside of handler errors.
+			for _, err := range err.Unwrap() {
+				collect(err)
+			}
+		default:
+			others = append(others, err)
+		}
+	}
+	collect(err)
+	se.Err = errors.Join(others...)
+	return se
+}
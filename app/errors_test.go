@@ -0,0 +1,63 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestShutdownErrorAs(t *testing.T) {
+	errClose := errors.New("close failed")
+	a := apptest.NewTestApp(t, app.WithShutdownOrder(app.OrderFIFO))
+	a.RegisterNamedShutdownHandler("db", func(context.Context) error { return errClose })
+	a.RegisterNamedShutdownHandler("cache", func(context.Context) error { return nil })
+
+	err := a.Shutdown(context.Background())
+	var se *app.ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Shutdown() = %v, want a *ShutdownError", err)
+	}
+	if len(se.Handlers) != 1 || se.Handlers[0].Name != "db" || !errors.Is(se.Handlers[0].Err, errClose) {
+		t.Errorf("ShutdownError.Handlers = %v, want only the db failure", se.Handlers)
+	}
+	if se.Err != nil {
+		t.Errorf("ShutdownError.Err = %v, want nil", se.Err)
+	}
+	if !errors.Is(err, errClose) {
+		t.Error("errors.Is does not find the handler error in the ShutdownError")
+	}
+	var he app.HandlerError
+	if !errors.As(err, &he) || he.Name != "db" {
+		t.Errorf("errors.As HandlerError = %v, want the db failure", he)
+	}
+}
+
+func TestShutdownErrorTimeout(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(20*time.Millisecond))
+	a.RegisterNamedShutdownHandler("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := a.Shutdown(context.Background())
+	var se *app.ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Shutdown() = %v, want a *ShutdownError", err)
+	}
+	if !errors.Is(se.Err, context.DeadlineExceeded) {
+		t.Errorf("ShutdownError.Err = %v, want the shutdown timeout", se.Err)
+	}
+}
+
+func TestShutdownSucceededReturnsNil(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	a.RegisterShutdownHandler(func(context.Context) error { return nil })
+
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() = %#v, want a true nil error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// GraceHook is called when the grace period starts or ends.
+type GraceHook func(context.Context)
+
+// OnGracePeriodStart adds a hook called when the grace period starts, right
+// after the app is marked not ready. Together with OnGracePeriodEnd, the
+// hooks of a graceful shutdown run in this order:
+//
+//  1. grace period start hooks, in registration order;
+//  2. the grace period itself;
+//  3. grace period end hooks, in registration order;
+//  4. the shutdown handlers.
+//
+// The hooks are not called when the main loop returns by itself, since the
+// app then shuts down without a grace period.
+func (a *App) OnGracePeriodStart(hook GraceHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.graceStartHooks = append(a.graceStartHooks, hook)
+}
+
+// OnGracePeriodEnd adds a hook called when the grace period ends, right
+// before the shutdown handlers, see OnGracePeriodStart.
+func (a *App) OnGracePeriodEnd(hook GraceHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.graceEndHooks = append(a.graceEndHooks, hook)
+}
+
+// runGraceHooks calls the given app hooks, in registration order.
+func (a *App) runGraceHooks(ctx context.Context, hooks *[]GraceHook) {
+	a.mu.Lock()
+	snapshot := append([]GraceHook(nil), *hooks...)
+	a.mu.Unlock()
+
+	for _, hook := range snapshot {
+		hook(ctx)
+	}
+}
+
+// gracefulShutdown marks the app not ready, waits for the grace period so
+// load balancers stop sending traffic and then calls Shutdown.
+// The grace period hooks run right before and right after the wait.
+// sigs receives the shutdown signals.
+// A zero or negative grace period skips the wait, and its log lines, entirely.
+// A second shutdown signal skips the rest of the grace period and a third
+// one forces the process to exit.
+func (a *App) gracefulShutdown(ctx context.Context, sigs <-chan os.Signal) error {
+	a.setState(StateShuttingDown)
+	a.cancelAppContext(ctx)
+	a.SetReady(false)
+	a.runGraceHooks(ctx, &a.graceStartHooks)
+
+	received := 1
+	if a.GracePeriod > 0 {
+		select {
+		case <-a.after(a.jitteredGracePeriod()):
+			a.log().Info("Grace period is over, initiating shutdown procedures...")
+		case <-a.idleChan():
+			a.log().Info("No work in flight anymore! Ending the grace period early, initiating shutdown procedures...")
+		case <-sigs:
+			received++
+			a.log().Warn("Second shutdown signal received! Skipping the rest of the grace period, initiating shutdown procedures...")
+		}
+	}
+
+	a.runGraceHooks(ctx, &a.graceEndHooks)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go a.forceExitOnSignal(sigs, received, stop)
+
+	_, err := a.runShutdown(ctx)
+	return err
+}
+
+// jitteredGracePeriod returns the grace period plus a random offset of up
+// to the jitter set with WithGracePeriodJitter.
+func (a *App) jitteredGracePeriod() time.Duration {
+	if a.graceJitter <= 0 {
+		return a.GracePeriod
+	}
+	n := int64(a.graceJitter) + 1
+	if a.jitterRand != nil {
+		return a.GracePeriod + time.Duration(a.jitterRand.Int64N(n))
+	}
+	return a.GracePeriod + time.Duration(rand.Int64N(n))
+}
+
+// graceNotice completes the log line announcing a graceful shutdown,
+// depending on whether there is a grace period to wait for.
+func (a *App) graceNotice() string {
+	if a.GracePeriod <= 0 {
+		return " Initiating shutdown procedures..."
+	}
+	return " Awaiting for grace period to end."
+}
+
+// forceExitOnSignal hard-kills the process once the third shutdown signal
+// arrives, counting the ones already received. It returns when stop is closed.
+func (a *App) forceExitOnSignal(sigs <-chan os.Signal, received int, stop <-chan struct{}) {
+	for {
+		select {
+		case <-sigs:
+			received++
+			if received < 3 {
+				a.log().Warn("Shutdown signal received while shutting down, send another one to force exit.")
+				continue
+			}
+			a.log().Error("Third shutdown signal received! Forcing exit now.")
+			a.forceExit(ExitForced)
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// forceExit terminates the process with the configured force-exit function.
+func (a *App) forceExit(code int) {
+	if a.forceExitFunc == nil {
+		os.Exit(code)
+	}
+	a.forceExitFunc(code)
+}
@@ -0,0 +1,49 @@
+package app_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestGracePeriodHooks(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var calls []string
+	a.OnGracePeriodStart(func(context.Context) { calls = append(calls, "start") })
+	a.OnGracePeriodEnd(func(context.Context) { calls = append(calls, "end") })
+	a.RegisterShutdownHandler(recordingHandler(&calls, "shutdown handler"))
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		a.TriggerShutdown("test")
+		<-ctx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if want := []string{"start", "end", "shutdown handler"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestZeroGracePeriodSkipsTheWait(t *testing.T) {
+	var buf syncBuffer
+	a := apptest.NewTestApp(t, app.WithLogOutput(&buf), app.WithGracePeriod(0))
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		a.TriggerShutdown("test")
+		<-ctx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "Awaiting for grace period") || strings.Contains(out, "Grace period is over") {
+		t.Errorf("grace period logs emitted with a zero grace period:\n%s", out)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExportShutdownGraph writes the shutdown handlers and their dependencies
+// to w as a Graphviz DOT graph, which can be rendered with
+// `dot -Tsvg` to check the teardown order. Every handler is a node labeled
+// with its position in the ShutdownPlan and its priority, dashed when it is
+// best effort, and every dependency is an edge from the handler to the one
+// it depends on, that is from the handler run first to the one run after.
+// With ConcurrentShutdown the handlers have no position and no edge.
+// The output only depends on the registered handlers, so it can be diffed.
+func (a *App) ExportShutdownGraph(w io.Writer) error {
+	handlers := a.orderedShutdownHandlers()
+
+	var b strings.Builder
+	b.WriteString("digraph shutdown {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for i, h := range handlers {
+		label := fmt.Sprintf("%d. %s\npriority %d", i+1, h.name, h.priority)
+		if a.ConcurrentShutdown {
+			label = fmt.Sprintf("%s\npriority %d", h.name, h.priority)
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s", strconv.Quote(h.name), strconv.Quote(label))
+		if h.bestEffort {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+	if !a.ConcurrentShutdown {
+		for _, h := range handlers {
+			for _, dep := range h.dependsOn {
+				// Unknown dependencies are ignored by Shutdown.
+				if !slices.ContainsFunc(handlers, func(other namedShutdownHandler) bool { return other.name == dep }) {
+					continue
+				}
+				fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(h.name), strconv.Quote(dep))
+			}
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
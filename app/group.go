@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// RunGroup runs functions together as the main loop of an app, with the
+// semantics of golang.org/x/sync/errgroup: the first function to return an
+// error cancels the context of the others, and the group finishes once they
+// all returned. The group context is also canceled as soon as the app starts
+// shutting down.
+type RunGroup struct {
+	app *App
+
+	mu sync.Mutex
+	// fns are all the functions of the group, started again on restarts.
+	fns      []MainLoopFunc
+	started  bool
+	finished bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	count    int
+	running  int
+	errs     []error
+	done     chan struct{}
+}
+
+// Group returns the run group of the app, whose functions are run by RunGroupAndWait.
+func (a *App) Group() *RunGroup {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.group == nil {
+		a.group = &RunGroup{app: a}
+	}
+	return a.group
+}
+
+// RunGroupAndWait behaves like Run, using the functions of the app run group
+// as the main loop. The joined errors of the group are the main loop error.
+func (a *App) RunGroupAndWait() int {
+	return a.lifecycle(a.baseContext(), a.Group().start).exitCode()
+}
+
+// RunAll behaves like RunAndWait but runs several main loops together, each
+// in its own goroutine. When a loop returns an error, the context of the
+// others is canceled so they can stop; once they all returned, the app shuts
+// down with all their errors joined, the first one first. Shutdown signals
+// cancel every loop at once.
+func (a *App) RunAll(loops ...MainLoopFunc) {
+	g := &RunGroup{app: a, fns: loops}
+	a.lifecycle(a.baseContext(), g.start)
+}
+
+// Go adds a function to the group. Functions added before the group starts
+// are launched when it does; afterwards they are launched right away, unless
+// the group already finished. When the app restarts, all the functions of the
+// group are launched again.
+func (g *RunGroup) Go(fn MainLoopFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.finished {
+		g.app.log().Warn("run group already finished, ignoring the added function")
+		return
+	}
+	g.fns = append(g.fns, fn)
+	if g.started {
+		g.spawn(fn)
+	}
+}
+
+// start launches the group functions and reports their joined errors once
+// they all returned.
+func (g *RunGroup) start(ctx context.Context) <-chan error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ctx, g.cancel = context.WithCancel(ctx)
+	g.started = true
+	g.finished = false
+	g.count = 0
+	g.errs = nil
+	g.done = make(chan struct{})
+
+	g.app.log().Info("Application main loops starting now!",
+		slog.Int("loops", len(g.fns)))
+	for _, fn := range g.fns {
+		g.spawn(fn)
+	}
+	if g.running == 0 {
+		g.finish()
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		<-g.done
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		result <- errors.Join(g.errs...)
+	}()
+	return result
+}
+
+// spawn runs fn in its own goroutine. g.mu must be held.
+func (g *RunGroup) spawn(fn MainLoopFunc) {
+	i := g.count
+	g.count++
+	g.running++
+	ctx := g.ctx
+	go func() {
+		var err error
+		if fn == nil {
+			err = ErrNilMainLoop
+		} else {
+			err = g.app.callMainLoop(ctx, fn)
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if err != nil {
+			g.errs = append(g.errs, fmt.Errorf("main loop %d: %w", i, err))
+			g.cancel()
+		}
+		g.running--
+		if g.running == 0 {
+			g.finish()
+		}
+	}()
+}
+
+// finish marks the group as finished. g.mu must be held.
+func (g *RunGroup) finish() {
+	g.finished = true
+	g.cancel()
+	close(g.done)
+}
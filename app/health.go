@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HealthCheck reports the health of a subsystem, returning nil when it is
+// healthy.
+type HealthCheck func(context.Context) error
+
+// HealthReport is the result of the health checks of an app.
+type HealthReport struct {
+	// Healthy is true when all checks passed.
+	Healthy bool
+	// Checks are the results of each check, in registration order.
+	Checks []CheckResult
+}
+
+// CheckResult is the result of a single health check.
+type CheckResult struct {
+	Name string
+	// Err is the error returned by the check, nil when it passed.
+	Err      error
+	Duration time.Duration
+}
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// RegisterHealthCheck adds a health check, identified by name in the health
+// reports. The checks also decide the readiness reported by ProbeServer.
+func (a *App) RegisterHealthCheck(name string, check HealthCheck) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.healthChecks = append(a.healthChecks, namedHealthCheck{name: name, check: check})
+}
+
+// Health runs all the health checks concurrently, each one bounded by
+// HealthCheckTimeout, and reports their results. An app without any check is
+// healthy.
+func (a *App) Health(ctx context.Context) HealthReport {
+	a.mu.Lock()
+	checks := append([]namedHealthCheck(nil), a.healthChecks...)
+	a.mu.Unlock()
+
+	report := HealthReport{Healthy: true, Checks: make([]CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = a.runHealthCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Err != nil {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// runHealthCheck runs a single health check, bounded by HealthCheckTimeout.
+func (a *App) runHealthCheck(ctx context.Context, c namedHealthCheck) CheckResult {
+	if a.HealthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.HealthCheckTimeout)
+		defer cancel()
+	}
+	start := a.now()
+	err := RunWithContext(ctx, func() error {
+		return c.check(ctx)
+	})
+	return CheckResult{Name: c.name, Err: err, Duration: a.since(start)}
+}
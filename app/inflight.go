@@ -0,0 +1,56 @@
+package app
+
+import "sync"
+
+// inflightTracker counts the operations in progress and signals when there
+// are none left.
+type inflightTracker struct {
+	mu    sync.Mutex
+	count int
+	// idle is closed whenever count drops to zero. It stays nil until the
+	// first operation is tracked, so apps that never track anything are not
+	// considered idle.
+	idle chan struct{}
+}
+
+// TrackInflight marks the start of an operation that should be completed
+// before shutting down and returns the function marking its end.
+// Once some work has been tracked, the grace period ends early as soon as
+// no tracked operation is in flight anymore.
+// The returned function is safe to call more than once.
+func (a *App) TrackInflight() (done func()) {
+	t := &a.inflight
+	t.mu.Lock()
+	if t.count == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.count++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.count--
+			if t.count == 0 {
+				close(t.idle)
+			}
+		})
+	}
+}
+
+// Inflight returns the number of tracked operations in progress.
+func (a *App) Inflight() int {
+	a.inflight.mu.Lock()
+	defer a.inflight.mu.Unlock()
+	return a.inflight.count
+}
+
+// idleChan returns a channel closed once no tracked operation is in flight,
+// or nil if nothing was ever tracked.
+func (a *App) idleChan() <-chan struct{} {
+	a.inflight.mu.Lock()
+	defer a.inflight.mu.Unlock()
+	return a.inflight.idle
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// packageLogger is the logger installed by NewDefaultApp.
+var packageLogger atomic.Pointer[slog.Logger]
+
+// Logger returns the logger of the default app, or slog.Default() until
+// NewDefaultApp is called, so that logging early in the program is never lost.
+func Logger() *slog.Logger {
+	if logger := packageLogger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// log returns the logger of the lifecycle messages: the lifecycle logger if
+// set, or else the app logger, falling back to the package Logger for apps
+// that were not built with NewApp.
+func (a *App) log() *slog.Logger {
+	if a.lifecycleLogger != nil {
+		return a.lifecycleLogger
+	}
+	return a.Logger()
+}
+
+// Logger returns the logger of the app, so that subsystems can log with the
+// same handler and app metadata. The lifecycle logger set with
+// WithLifecycleLogger is only used by the app itself.
+func (a *App) Logger() *slog.Logger {
+	if a.logger == nil {
+		return Logger()
+	}
+	return a.logger
+}
+
+type loggerKey struct{}
+
+// LoggerFromContext returns the app logger attached to the contexts the app
+// gives to its main loop and to its startup, reload and shutdown handlers,
+// so that they can log consistently without a reference to the app.
+// It returns the package Logger, slog.Default() unless NewDefaultApp was
+// called, when there is none.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return Logger()
+}
+
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// logConfig describes the logger built by NewApp when no explicit logger
+// is given with WithLogger.
+type logConfig struct {
+	level  slog.Leveler
+	output io.Writer
+	text   bool
+	// auto picks text when output is a terminal.
+	auto bool
+	// metadata are the attributes added to every log line.
+	metadata []any
+}
+
+// newLogger builds a logger writing JSON to os.Stdout unless configured otherwise.
+func (c logConfig) newLogger() *slog.Logger {
+	output := c.output
+	if output == nil {
+		output = os.Stdout
+	}
+	opts := &slog.HandlerOptions{Level: c.level}
+	if c.text || (c.auto && isTerminal(output)) {
+		return slog.New(slog.NewTextHandler(output, opts))
+	}
+	return slog.New(slog.NewJSONHandler(output, opts))
+}
+
+// enrich adds the configured metadata to logger.
+func (c logConfig) enrich(logger *slog.Logger) *slog.Logger {
+	if len(c.metadata) == 0 {
+		return logger
+	}
+	return logger.With(c.metadata...)
+}
+
+// flushLogger calls the logger flush function, if any. Its failure is
+// written to os.Stderr since the logger may be what failed.
+func (a *App) flushLogger() {
+	if a.loggerFlush == nil {
+		return
+	}
+	if err := a.loggerFlush(); err != nil {
+		fmt.Fprintf(os.Stderr, "app: flushing the logger failed: %v\n", err)
+	}
+}
+
+// isTerminal reports whether w is a terminal, such as os.Stdout when the
+// program is not piped.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// instanceID identifies the running process among the app replicas.
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
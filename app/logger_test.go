@@ -0,0 +1,136 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the app
+// goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestInjectedLogger(t *testing.T) {
+	for name, inject := range map[string]func(*slog.Logger) *app.App{
+		"WithLogger": func(logger *slog.Logger) *app.App {
+			return apptest.NewTestApp(t, app.WithLogger(logger))
+		},
+		"SetLogger": func(logger *slog.Logger) *app.App {
+			a := apptest.NewTestApp(t)
+			a.SetLogger(logger)
+			return a
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf syncBuffer
+			a := inject(slog.New(slog.NewTextHandler(&buf, nil)))
+			a.RegisterNamedShutdownHandler("db", func(context.Context) error { return nil })
+
+			apptest.Shutdown(t, a)
+			if !strings.Contains(buf.String(), "handler=db") {
+				t.Errorf("the injected logger did not get the shutdown handler logs:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSetNilLoggerFallsBackToDefault(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	a.SetLogger(nil)
+	if a.Logger() != slog.Default() {
+		t.Error("SetLogger(nil) did not fall back to slog.Default()")
+	}
+}
+
+func TestLogLevelFiltering(t *testing.T) {
+	var buf syncBuffer
+	a := apptest.NewTestApp(t, app.WithLogOutput(&buf), app.WithLogLevel(slog.LevelWarn))
+
+	a.Logger().Info("hidden")
+	a.Logger().Warn("shown")
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("an info line got through the warn level:\n%s", out)
+	}
+	if !strings.Contains(out, "level=WARN msg=shown") {
+		t.Errorf("the warn line is missing from the text logs:\n%s", out)
+	}
+}
+
+func TestLogsDefaultToJSON(t *testing.T) {
+	var buf syncBuffer
+	a := app.NewApp(context.Background(), app.WithLogOutput(&buf))
+
+	a.Logger().Info("hello")
+	if !strings.Contains(buf.String(), `"msg":"hello"`) {
+		t.Errorf("the logs are not JSON:\n%s", buf.String())
+	}
+}
+
+func TestExplicitLoggerWinsOverLogOptions(t *testing.T) {
+	var explicit, output syncBuffer
+	a := apptest.NewTestApp(t,
+		app.WithLogger(slog.New(slog.NewTextHandler(&explicit, nil))),
+		app.WithLogOutput(&output),
+		app.WithLogLevel(slog.LevelError),
+	)
+
+	a.Logger().Info("hello")
+	if !strings.Contains(explicit.String(), "msg=hello") {
+		t.Errorf("the explicit logger did not get the line:\n%s", explicit.String())
+	}
+	if output.String() != "" {
+		t.Errorf("the configured output got lines despite the explicit logger:\n%s", output.String())
+	}
+}
+
+func TestLoggerFromContextInMainLoop(t *testing.T) {
+	var buf syncBuffer
+	a := apptest.NewTestApp(t, app.WithLogOutput(&buf), app.WithAppMetadata("billing", "1.2.3"))
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		if app.LoggerFromContext(ctx) != a.Logger() {
+			t.Error("LoggerFromContext() is not the app logger")
+		}
+		app.LoggerFromContext(ctx).Info("from the loop")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "from the loop") && !strings.Contains(line, "billing") {
+			t.Errorf("the loop log line lacks the app metadata: %s", line)
+		}
+	}
+	if !strings.Contains(buf.String(), "from the loop") {
+		t.Errorf("the loop log line is missing:\n%s", buf.String())
+	}
+}
+
+func TestLoggerFromContextDefault(t *testing.T) {
+	if app.LoggerFromContext(context.Background()) != slog.Default() {
+		t.Error("LoggerFromContext() without app logger is not slog.Default()")
+	}
+}
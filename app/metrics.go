@@ -0,0 +1,79 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// MetricEvent is a lifecycle event reported to the metrics recorder.
+type MetricEvent int
+
+const (
+	// MetricStateChanged is reported when the app enters a new state.
+	MetricStateChanged MetricEvent = iota
+	// MetricShutdownInitiated is reported when the shutdown handlers start.
+	MetricShutdownInitiated
+	// MetricHandlerFailed is reported when a shutdown handler returns an error.
+	MetricHandlerFailed
+	// MetricShutdownFinished is reported once all shutdown handlers ran.
+	MetricShutdownFinished
+)
+
+func (e MetricEvent) String() string {
+	switch e {
+	case MetricStateChanged:
+		return "state_changed"
+	case MetricShutdownInitiated:
+		return "shutdown_initiated"
+	case MetricHandlerFailed:
+		return "handler_failed"
+	case MetricShutdownFinished:
+		return "shutdown_finished"
+	default:
+		return "unknown"
+	}
+}
+
+// LifecycleMetrics is a snapshot of the lifecycle counters of an app.
+type LifecycleMetrics struct {
+	// ShutdownsInitiated counts the shutdowns that ran the shutdown handlers.
+	ShutdownsInitiated uint64
+	// HandlerFailures counts the shutdown handlers that returned an error,
+	// critical or not.
+	HandlerFailures uint64
+	// State is the current lifecycle state.
+	State AppState
+	// LastShutdownDuration is the time the last shutdown handlers took
+	// altogether, zero until a shutdown finished.
+	LastShutdownDuration time.Duration
+}
+
+// MetricsRecorder is called with the updated metrics on every lifecycle
+// event, for bridging them to a metrics system such as Prometheus.
+// It is called synchronously and must not block.
+type MetricsRecorder func(MetricEvent, LifecycleMetrics)
+
+// lifecycleMetrics holds the lifecycle counters of an app.
+type lifecycleMetrics struct {
+	shutdowns            atomic.Uint64
+	handlerFailures      atomic.Uint64
+	lastShutdownDuration atomic.Int64
+}
+
+// Metrics returns the current lifecycle metrics of the app.
+func (a *App) Metrics() LifecycleMetrics {
+	return LifecycleMetrics{
+		ShutdownsInitiated:   a.metrics.shutdowns.Load(),
+		HandlerFailures:      a.metrics.handlerFailures.Load(),
+		State:                a.State(),
+		LastShutdownDuration: time.Duration(a.metrics.lastShutdownDuration.Load()),
+	}
+}
+
+// recordMetric hands the current metrics to the recorder, if any.
+func (a *App) recordMetric(event MetricEvent) {
+	if a.metricsRecorder == nil {
+		return
+	}
+	a.metricsRecorder(event, a.Metrics())
+}
@@ -0,0 +1,315 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// Option configures an App during construction.
+type Option func(*App)
+
+// WithGracePeriod sets the time to wait after a shutdown signal
+// before the shutdown handlers are called.
+func WithGracePeriod(d time.Duration) Option {
+	return func(a *App) {
+		a.GracePeriod = d
+		a.gracePeriodSet = true
+	}
+}
+
+// WithShutdownTimeout sets the time budget for all shutdown handlers.
+// Zero means no timeout.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.ShutdownTimeout = d
+		a.shutdownTimeoutSet = true
+	}
+}
+
+// WithLogger sets the logger used by the app.
+// A nil logger falls back to slog.Default().
+// It takes precedence over WithLogLevel, WithLogOutput, WithTextLogs and
+// WithAutoLogFormat.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *App) {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		a.logger = logger
+	}
+}
+
+// WithShutdownOrder sets the order in which shutdown handlers are called.
+func WithShutdownOrder(order ShutdownOrder) Option {
+	return func(a *App) {
+		a.ShutdownOrder = order
+	}
+}
+
+// WithConcurrentShutdown makes the app run all shutdown handlers concurrently.
+// By default they are run sequentially, following ShutdownOrder.
+func WithConcurrentShutdown() Option {
+	return func(a *App) {
+		a.ConcurrentShutdown = true
+	}
+}
+
+// WithStartupTimeout sets the time budget for all startup handlers.
+// Zero means no timeout.
+func WithStartupTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.StartupTimeout = d
+	}
+}
+
+// WithReloadTimeout sets the time budget for all reload handlers.
+// Zero means no timeout.
+func WithReloadTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.ReloadTimeout = d
+	}
+}
+
+// WithSignals sets the signals that trigger a graceful shutdown,
+// replacing the default SIGINT and SIGTERM.
+// With no signals, the app never shuts down because of a signal.
+func WithSignals(sigs ...os.Signal) Option {
+	return func(a *App) {
+		a.Signals = sigs
+	}
+}
+
+// WithLogLevel sets the minimum level of the app logger.
+func WithLogLevel(level slog.Level) Option {
+	return func(a *App) {
+		a.logConfig.level = level
+	}
+}
+
+// WithLogOutput sets where the app logger writes to, instead of os.Stdout.
+func WithLogOutput(w io.Writer) Option {
+	return func(a *App) {
+		a.logConfig.output = w
+	}
+}
+
+// WithTextLogs makes the app logger write text instead of JSON.
+func WithTextLogs() Option {
+	return func(a *App) {
+		a.logConfig.text = true
+	}
+}
+
+// WithLifecycleLogger sets the logger of the messages of the app itself,
+// such as the startup and shutdown messages, so that they can be routed
+// apart from the application logs. Logger keeps returning the app logger.
+// Without WithLogger, the lifecycle logger is the app logger too.
+func WithLifecycleLogger(logger *slog.Logger) Option {
+	return func(a *App) {
+		a.lifecycleLogger = logger
+	}
+}
+
+// WithLoggerFlush sets a function persisting the log lines buffered by an
+// asynchronous logger. It is called as the very last step of Shutdown, after
+// all the handlers, and again once the app terminated, after its final log
+// line, so it must be safe to call more than once. Its failure is written to
+// os.Stderr.
+func WithLoggerFlush(flush func() error) Option {
+	return func(a *App) {
+		a.loggerFlush = flush
+	}
+}
+
+// WithAutoLogFormat makes the app logger write text when its output is a
+// terminal, for developers, and JSON otherwise, for log collectors.
+// Without it, the app logs JSON unless WithTextLogs is given.
+func WithAutoLogFormat() Option {
+	return func(a *App) {
+		a.logConfig.auto = true
+	}
+}
+
+// WithClock sets the clock used to wait for the grace period and to measure
+// shutdown durations. A nil clock falls back to the real one.
+func WithClock(clock Clock) Option {
+	return func(a *App) {
+		if clock == nil {
+			clock = realClock{}
+		}
+		a.clock = clock
+	}
+}
+
+// WithoutEmptyShutdownWarning disables the warning logged when the app shuts
+// down without any shutdown handler, for apps that legitimately have none.
+func WithoutEmptyShutdownWarning() Option {
+	return func(a *App) {
+		a.quietEmptyShutdown = true
+	}
+}
+
+// WithoutStartupSummary disables the log line summarizing the grace period,
+// the shutdown timeout, the shutdown handlers and the handled signals, logged
+// once the startup handlers succeeded.
+func WithoutStartupSummary() Option {
+	return func(a *App) {
+		a.quietStartupSummary = true
+	}
+}
+
+// WithPanicOnShutdownError makes Shutdown panic with the aggregated error
+// once all handlers ran, if any of them failed, instead of returning it.
+// It suits fail-fast environments such as CI and staging.
+func WithPanicOnShutdownError() Option {
+	return func(a *App) {
+		a.panicOnShutdownError = true
+	}
+}
+
+// WithAppMetadata adds the app name and version, along with an instance id
+// made of the hostname and the process id, to every log line of the app.
+func WithAppMetadata(name, version string) Option {
+	return func(a *App) {
+		a.logConfig.metadata = []any{
+			slog.String("app", name),
+			slog.String("version", version),
+			slog.String("instance_id", instanceID()),
+		}
+	}
+}
+
+// WithForceExitFunc sets the function called instead of os.Exit when the app
+// is forced to exit, for instance by a third shutdown signal. It can flush
+// logs before exiting, or panic in tests. If it returns, the app keeps
+// shutting down normally.
+func WithForceExitFunc(exit func(code int)) Option {
+	return func(a *App) {
+		a.forceExitFunc = exit
+	}
+}
+
+// WithWatchdogMargin sets how long the shutdown may overrun ShutdownTimeout
+// before the watchdog forces the process to exit with ExitForced, which only
+// happens when a handler ignores its context. It defaults to
+// DefaultWatchdogMargin; zero disables the watchdog.
+func WithWatchdogMargin(margin time.Duration) Option {
+	return func(a *App) {
+		a.watchdogMargin = margin
+	}
+}
+
+// WithMetricsRecorder sets a recorder called with the lifecycle metrics on
+// every lifecycle event, see App.Metrics.
+func WithMetricsRecorder(recorder MetricsRecorder) Option {
+	return func(a *App) {
+		a.metricsRecorder = recorder
+	}
+}
+
+// WithReExecSignal makes the app call ReExec with the given files when it
+// receives sig, typically syscall.SIGUSR2, for graceful restarts of network
+// daemons. sig must be neither one of Signals nor mapped with
+// WithSignalAction. Re-exec is disabled by default, and the option does
+// nothing on platforms without exec(2).
+func WithReExecSignal(sig os.Signal, files ...*os.File) Option {
+	return func(a *App) {
+		a.reExecSignal = sig
+		a.reExecFiles = files
+	}
+}
+
+// WithEqualTimeBudget makes sequential shutdowns bounded by ShutdownTimeout
+// give each handler an equal share of the time left: before each handler,
+// the remaining time is divided by the number of handlers left, so that one
+// slow handler cannot starve the next ones. Handlers registered with their
+// own timeout keep it. It has no effect with ConcurrentShutdown.
+func WithEqualTimeBudget() Option {
+	return func(a *App) {
+		a.equalTimeBudget = true
+	}
+}
+
+// WithMaxLifetime makes the app shut down gracefully once its main loop ran
+// for d, whether or not a signal was received, to recycle long-lived
+// processes or bound job runners. Zero, the default, means no limit.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(a *App) {
+		a.maxLifetime = d
+	}
+}
+
+// WithOnTerminated sets a function called once the app terminated, after the
+// shutdown handlers and right before Done is closed, with the error RunE
+// returns. Unlike a shutdown handler, it runs last and is not bounded by
+// ShutdownTimeout, so it suits final work such as writing a clean shutdown
+// marker. Only the last function given is kept.
+func WithOnTerminated(fn func(err error)) Option {
+	return func(a *App) {
+		a.onTerminated = fn
+	}
+}
+
+// WithSignalAction sets what the app does when it receives sig, overriding
+// the default actions: the Signals shut the app down gracefully and SIGHUP
+// reloads it. Mapping a signal to several actions, or mapping the re-exec
+// signal, makes NewApp panic.
+func WithSignalAction(sig os.Signal, action SignalAction) Option {
+	return func(a *App) {
+		a.signalMappings = append(a.signalMappings, signalMapping{sig: sig, action: action})
+	}
+}
+
+// WithEnvDefaults makes the app read its grace period and shutdown timeout
+// from the APP_GRACE_PERIOD and APP_SHUTDOWN_TIMEOUT environment variables,
+// such as "10s", unless they are set with WithGracePeriod or
+// WithShutdownTimeout. Invalid values are logged and ignored.
+func WithEnvDefaults() Option {
+	return func(a *App) {
+		a.envDefaults = true
+	}
+}
+
+// WithControlSocket makes the app listen on a Unix domain socket at path for
+// commands, one per line, from sidecars or orchestrators without access to
+// signals: "shutdown" triggers a graceful shutdown and "status" replies with
+// the state of the app. The socket is opened once the startup handlers
+// succeeded, failing the startup if it cannot be, and removed by a shutdown
+// handler.
+func WithControlSocket(path string) Option {
+	return func(a *App) {
+		a.controlSocket = path
+	}
+}
+
+// WithRequireRunningLoop makes the main loop returning nil by itself, before
+// any shutdown was initiated, an error: ErrLoopExitedUnexpectedly, with the
+// ExitMainLoopError exit code. It suits servers meant to run until they are
+// told to stop, whereas batch jobs legitimately return nil once done.
+func WithRequireRunningLoop() Option {
+	return func(a *App) {
+		a.requireRunningLoop = true
+	}
+}
+
+// WithGracePeriodJitter adds a random offset, between zero and max, to the
+// grace period of a graceful shutdown, so that replicas told to stop at the
+// same time do not all drain at once. There is no jitter when the grace
+// period is zero. The grace period still ends early once no work tracked
+// with TrackInflight is in flight: the jitter only lengthens the longest wait.
+func WithGracePeriodJitter(max time.Duration) Option {
+	return func(a *App) {
+		a.graceJitter = max
+	}
+}
+
+// WithJitterRand sets the random number generator drawing the grace period
+// jitter, such as rand.New(rand.NewPCG(1, 2)) for deterministic tests.
+func WithJitterRand(r *rand.Rand) Option {
+	return func(a *App) {
+		a.jitterRand = r
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error a recovered panic is converted to.
+type PanicError struct {
+	// Value is the value the code panicked with.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// newPanicError must be called from the deferred function that recovered
+// the panic so that the stack trace points at it.
+func newPanicError(value any) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,77 @@
+package app
+
+import "context"
+
+// closedChan is returned by Resumed when the app is not paused.
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// Pause asks the main loop to stop taking new work, for maintenance windows,
+// without shutting down: the process stays up and idles until Resume is
+// called. Main loops must cooperate by calling WorkAllowed, or watching
+// Resumed, before taking work. Pausing a paused app does nothing.
+// The ActionTogglePause signal action pauses and resumes the app.
+func (a *App) Pause() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.resumed != nil {
+		return
+	}
+	a.resumed = make(chan struct{})
+	a.log().Info("App paused.")
+}
+
+// Resume lets the main loop take work again after Pause.
+// Resuming an app that is not paused does nothing.
+func (a *App) Resume() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.resumed == nil {
+		return
+	}
+	close(a.resumed)
+	a.resumed = nil
+	a.log().Info("App resumed.")
+}
+
+// Paused reports whether the app is paused.
+func (a *App) Paused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.resumed != nil
+}
+
+// Resumed returns a channel closed once work is allowed: right away when the
+// app is not paused, or else when it is resumed.
+func (a *App) Resumed() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.resumed == nil {
+		return closedChan
+	}
+	return a.resumed
+}
+
+// WorkAllowed blocks while the app is paused. It returns nil once work is
+// allowed, or the context error if ctx is done first, such as when the app
+// shuts down while paused.
+func (a *App) WorkAllowed(ctx context.Context) error {
+	select {
+	case <-a.Resumed():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// togglePause pauses a running app, or resumes a paused one.
+func (a *App) togglePause() {
+	if a.Paused() {
+		a.Resume()
+		return
+	}
+	a.Pause()
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// SetReady sets whether the app is ready to receive traffic, as reported by
+// the readiness probe. The app becomes ready automatically once its startup
+// handlers succeed and becomes not ready as soon as shutdown begins, at the
+// start of the grace period.
+func (a *App) SetReady(ready bool) {
+	a.ready.Store(ready)
+}
+
+// Ready reports whether the app is ready to receive traffic.
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+// ProbeServer serves the liveness (/healthz) and readiness (/readyz)
+// probes of an app over HTTP. The app is reported ready when it is Ready and
+// all its health checks pass; failing checks are listed in the response.
+type ProbeServer struct {
+	app    *App
+	server *http.Server
+}
+
+// NewProbeServer creates a probe server for the app listening on addr.
+func NewProbeServer(a *App, addr string) *ProbeServer {
+	p := &ProbeServer{app: a}
+	p.server = &http.Server{
+		Addr:    addr,
+		Handler: p.Handler(),
+	}
+	return p
+}
+
+// Handler returns the HTTP handler serving the probes, for mounting them
+// on an existing server.
+func (p *ProbeServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !p.app.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready"))
+			return
+		}
+		if report := p.app.Health(r.Context()); !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready"))
+			for _, check := range report.Checks {
+				if check.Err != nil {
+					_, _ = fmt.Fprintf(w, "\n%s: %s", check.Name, check.Err)
+				}
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready"))
+	})
+	return mux
+}
+
+// Start starts listening and serves the probes in the background.
+func (p *ProbeServer) Start() error {
+	ln, err := net.Listen("tcp", p.server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := p.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.app.log().Error("probe server failed",
+				slog.String("module", "app/probe"),
+				slog.String("source", "ProbeServer.Start"),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+	return nil
+}
+
+// Shutdown gracefully stops the probe server. It is a ShutdownHandler.
+// The probes should stay available for as long as possible, so register it
+// before any other shutdown handler: with the default LIFO order it runs last.
+func (p *ProbeServer) Shutdown(ctx context.Context) error {
+	return p.server.Shutdown(ctx)
+}
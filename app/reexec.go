@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InheritedFDsEnv is the environment variable listing, comma separated, the
+// file descriptors a process re-executed by ReExec inherited.
+const InheritedFDsEnv = "APP_INHERITED_FDS"
+
+// ErrReExecUnsupported is returned by ReExec on platforms without exec(2).
+var ErrReExecUnsupported = errors.New("re-exec is not supported on this platform")
+
+// ReExec runs the shutdown handlers and then replaces the process with a new
+// instance of the same binary, with the same arguments and environment, for
+// restarts a reload cannot handle. The given files, typically the files of
+// the listeners obtained with (*net.TCPListener).File, stay open in the new
+// process, which gets them back with InheritedFiles.
+//
+// ReExec only returns on failure. When the new instance cannot be prepared,
+// for instance because a file cannot be passed on, it returns before running
+// the shutdown handlers, and the app keeps running. The process is
+// re-executed even if some shutdown handlers failed, their errors are only
+// logged.
+func (a *App) ReExec(ctx context.Context, files ...*os.File) error {
+	cmd, err := prepareReExec(files)
+	if err != nil {
+		return err
+	}
+	return a.reExec(ctx, cmd)
+}
+
+// reExecCommand is the command ReExec replaces the process with.
+type reExecCommand struct {
+	path  string
+	env   []string
+	files int
+}
+
+// prepareReExec builds the command re-executing the app, passing files on.
+func prepareReExec(files []*os.File) (reExecCommand, error) {
+	if !reExecSupported {
+		return reExecCommand{}, ErrReExecUnsupported
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return reExecCommand{}, fmt.Errorf("re-exec: %w", err)
+	}
+
+	env := slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, InheritedFDsEnv+"=")
+	})
+	if len(files) > 0 {
+		fds := make([]string, len(files))
+		for i, f := range files {
+			if err := inheritable(f); err != nil {
+				return reExecCommand{}, fmt.Errorf("re-exec: cannot pass %s: %w", f.Name(), err)
+			}
+			fds[i] = strconv.FormatUint(uint64(f.Fd()), 10)
+		}
+		env = append(env, InheritedFDsEnv+"="+strings.Join(fds, ","))
+	}
+	return reExecCommand{path: path, env: env, files: len(files)}, nil
+}
+
+// reExec runs the shutdown handlers and then replaces the process with cmd.
+func (a *App) reExec(ctx context.Context, cmd reExecCommand) error {
+	_, shutdownErr := a.runShutdown(ctx)
+	a.log().Info("Re-executing the app now!",
+		slog.String("path", cmd.path),
+		slog.Int("inherited_files", cmd.files),
+	)
+	err := execve(cmd.path, os.Args, cmd.env)
+	return errors.Join(shutdownErr, fmt.Errorf("re-exec %s: %w", cmd.path, err))
+}
+
+// InheritedFiles returns the files passed to ReExec by the process this one
+// replaced, in the order they were given, or nil if there are none.
+// It consumes InheritedFDsEnv, so that it is not passed on to child
+// processes: later calls return nil.
+func InheritedFiles() []*os.File {
+	value, ok := os.LookupEnv(InheritedFDsEnv)
+	if !ok {
+		return nil
+	}
+	os.Unsetenv(InheritedFDsEnv)
+
+	var files []*os.File
+	for _, field := range strings.Split(value, ",") {
+		fd, err := strconv.ParseUint(field, 10, 0)
+		if err != nil {
+			Logger().Warn("ignoring invalid inherited file descriptor",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.InheritedFiles"),
+				slog.String("fd", field),
+			)
+			continue
+		}
+		files = append(files, os.NewFile(uintptr(fd), "inherited-"+field))
+	}
+	return files
+}
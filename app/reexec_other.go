@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package app
+
+import "os"
+
+const reExecSupported = false
+
+func inheritable(*os.File) error {
+	return ErrReExecUnsupported
+}
+
+func execve(string, []string, []string) error {
+	return ErrReExecUnsupported
+}
@@ -0,0 +1,23 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package app
+
+import (
+	"os"
+	"syscall"
+)
+
+const reExecSupported = true
+
+// inheritable clears the close-on-exec flag Go sets on every file, so that
+// f stays open across exec.
+func inheritable(f *os.File) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_SETFD, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func execve(path string, args, env []string) error {
+	return syscall.Exec(path, args, env)
+}
@@ -0,0 +1,79 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package app_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+// closedFile returns a file whose descriptor cannot be passed on to a new
+// instance.
+func closedFile(t *testing.T) *os.File {
+	f, err := os.CreateTemp(t.TempDir(), "reexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return f
+}
+
+func TestReExecKeepsRunningWhenItCannotBePrepared(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	shutdowns := 0
+	a.RegisterShutdownHandler(func(context.Context) error {
+		shutdowns++
+		return nil
+	})
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		if err := a.ReExec(ctx, closedFile(t)); err == nil {
+			t.Error("ReExec() = nil, want an error for a closed file")
+		}
+		if shutdowns != 0 {
+			t.Errorf("ReExec ran %d shutdowns before failing, want none", shutdowns)
+		}
+		if got := a.State(); got != app.StateRunning {
+			t.Errorf("State() = %s after ReExec failed, want %s", got, app.StateRunning)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if shutdowns != 1 {
+		t.Errorf("shutdown handler ran %d times, want 1", shutdowns)
+	}
+}
+
+func TestReExecSignalKeepsRunningWhenItCannotBePrepared(t *testing.T) {
+	// Keeps the process alive should the signal arrive before the app
+	// listens for it.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR2)
+	defer signal.Stop(guard)
+
+	a := apptest.NewTestApp(t, app.WithReExecSignal(syscall.SIGUSR2, closedFile(t)))
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		for range 20 {
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+			select {
+			case <-ctx.Done():
+				return errors.New("the app stopped on a re-exec it could not prepare")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ReloadHandler reloads part of the app, typically its configuration,
+// while the main loop keeps running.
+type ReloadHandler func(context.Context) error
+
+// RegisterReloadHandler adds a handler to be called, in registration order,
+// whenever the app receives a SIGHUP.
+func (a *App) RegisterReloadHandler(handler ReloadHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reloadHandlers = append(a.reloadHandlers, handler)
+}
+
+// reload calls every reload handler, bounded by ReloadTimeout.
+// Failures and timeouts are logged and returned, but never stop the app.
+func (a *App) reload(ctx context.Context) error {
+	a.mu.Lock()
+	handlers := append([]ReloadHandler(nil), a.reloadHandlers...)
+	a.mu.Unlock()
+
+	a.log().Info("Reload signal received, reloading...",
+		slog.Int("handlers", len(handlers)))
+
+	if a.ReloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.ReloadTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for i, handler := range handlers {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			a.log().Error("reload timed out, skipping the remaining reload handlers",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.reload"),
+				slog.Duration("timeout", a.ReloadTimeout),
+			)
+			errs = append(errs, fmt.Errorf("reload timed out after %s: %w", a.ReloadTimeout, context.DeadlineExceeded))
+			break
+		}
+		if err := handler(ctx); err != nil {
+			a.log().Error("error executing reload handler",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.reload"),
+				slog.Int("index", i),
+				slog.String("error", err.Error()),
+			)
+			errs = append(errs, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err == nil {
+		a.log().Info("Reload finished.")
+	} else {
+		a.log().Error("Reload finished with errors.",
+			slog.String("error", err.Error()))
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package app
+
+import "context"
+
+// ErrorReporter receives the errors of the app, for shipping them to an
+// error tracking service.
+type ErrorReporter func(context.Context, error)
+
+// RegisterErrorReporter adds a reporter called with every recovered main loop
+// panic, main loop error and shutdown handler error. All registered reporters
+// are called, in registration order. The errors are logged whether or not
+// reporters are registered.
+func (a *App) RegisterErrorReporter(reporter ErrorReporter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errorReporters = append(a.errorReporters, reporter)
+}
+
+// reportError hands err to every registered reporter.
+func (a *App) reportError(ctx context.Context, err error) {
+	a.mu.Lock()
+	reporters := append([]ErrorReporter(nil), a.errorReporters...)
+	a.mu.Unlock()
+
+	for _, reporter := range reporters {
+		reporter(ctx, err)
+	}
+}
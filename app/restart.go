@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrRestart can be returned, possibly wrapped, by a main loop run with
+	// RunWithRestart to ask for a restart, even when the policy does not
+	// restart on nil. The restart still counts toward MaxRetries.
+	ErrRestart = errors.New("main loop restart requested")
+	// ErrStop can be returned, possibly wrapped, by a main loop run with
+	// RunWithRestart to shut the app down right away, even if retries remain.
+	ErrStop = errors.New("main loop stop requested")
+)
+
+// RestartPolicy controls how RunWithRestart restarts a failing main loop.
+type RestartPolicy struct {
+	// MaxRetries is the maximum number of restarts. A negative value means
+	// the main loop is restarted forever.
+	MaxRetries int
+	// Backoff is the time to wait before each restart.
+	Backoff time.Duration
+	// RestartOnNil also restarts the main loop when it returns nil.
+	RestartOnNil bool
+}
+
+// RunWithRestart behaves like RunAndWait but restarts the main loop according
+// to policy instead of shutting down on its first error. Shutdown signals
+// interrupt the restarts immediately. Once the retries are exhausted, the app
+// shuts down with the last error.
+// The main loop can return ErrStop to shut down without being restarted and
+// ErrRestart to be restarted even if it has nothing to report.
+func (a *App) RunWithRestart(mainLoop MainLoopFunc, policy RestartPolicy) {
+	if mainLoop == nil {
+		a.RunAndWait(nil)
+		return
+	}
+	a.RunAndWait(a.restarting(mainLoop, policy))
+}
+
+// restarting wraps mainLoop so it is restarted according to policy.
+func (a *App) restarting(mainLoop MainLoopFunc, policy RestartPolicy) MainLoopFunc {
+	return func(ctx context.Context) error {
+		for attempt := 1; ; attempt++ {
+			err := a.callMainLoop(ctx, mainLoop)
+			if ctx.Err() != nil {
+				return err
+			}
+			if errors.Is(err, ErrStop) {
+				a.log().Info("Main loop asked to stop, not restarting it.",
+					slog.Int("attempt", attempt))
+				return err
+			}
+			if err == nil && !policy.RestartOnNil {
+				return nil
+			}
+			if policy.MaxRetries >= 0 && attempt > policy.MaxRetries {
+				a.log().Error("Main loop restarts exhausted.",
+					slog.Int("attempt", attempt))
+				return err
+			}
+
+			attrs := []any{slog.Int("attempt", attempt), slog.Duration("backoff", policy.Backoff)}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			a.log().Warn("Main loop returned, restarting it...", attrs...)
+
+			select {
+			case <-a.after(policy.Backoff):
+			case <-ctx.Done():
+				return err
+			}
+		}
+	}
+}
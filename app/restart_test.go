@@ -0,0 +1,39 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestRunWithRestartStopsOnErrStop(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	runs := 0
+	a.RunWithRestart(func(context.Context) error {
+		runs++
+		return fmt.Errorf("fatal: %w", app.ErrStop)
+	}, app.RestartPolicy{MaxRetries: 5})
+
+	if runs != 1 {
+		t.Errorf("main loop ran %d times, want 1", runs)
+	}
+}
+
+func TestRunWithRestartRestartsOnErrRestart(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	runs := 0
+	a.RunWithRestart(func(context.Context) error {
+		runs++
+		if runs == 1 {
+			return app.ErrRestart
+		}
+		return nil
+	}, app.RestartPolicy{MaxRetries: 5})
+
+	if runs != 2 {
+		t.Errorf("main loop ran %d times, want 2: restarted on ErrRestart, not on nil", runs)
+	}
+}
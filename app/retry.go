@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RegisterRetryingShutdownHandler adds a handler to be called during Shutdown
+// that is retried on error, up to attempts times in total. The wait between
+// two attempts starts at backoff and doubles after each attempt. The handler
+// is never retried past the shutdown context deadline. Only the error of the
+// last attempt counts toward the Shutdown error.
+func (a *App) RegisterRetryingShutdownHandler(handler ShutdownHandler, attempts int, backoff time.Duration) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{fn: a.retrying(handler, attempts, backoff)})
+}
+
+// retrying wraps handler so it is retried with an exponential backoff.
+func (a *App) retrying(handler func(context.Context) error, attempts int, backoff time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		wait := backoff
+		for attempt := 1; ; attempt++ {
+			err := handler(ctx)
+			if err == nil || attempt >= attempts {
+				return err
+			}
+			// The deadline is in real time, whatever the app clock.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				a.log().Warn("not retrying, the next attempt would exceed the deadline",
+					slog.Int("attempt", attempt),
+					slog.String("error", err.Error()),
+				)
+				return err
+			}
+
+			a.log().Warn("attempt failed, retrying",
+				slog.Int("attempt", attempt),
+				slog.Int("attempts", attempts),
+				slog.Duration("backoff", wait),
+				slog.String("error", err.Error()),
+			)
+			select {
+			case <-a.after(wait):
+			case <-ctx.Done():
+				return err
+			}
+			wait *= 2
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+// advanceWhileWaiting advances clock by d every time the code under test
+// waits on it, until done is closed.
+func advanceWhileWaiting(clock *apptest.FakeClock, d time.Duration, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(time.Millisecond):
+			if clock.Waiters() > 0 {
+				clock.Advance(d)
+			}
+		}
+	}
+}
+
+func TestRetryingShutdownHandlerWithFakeClockAhead(t *testing.T) {
+	clock := apptest.NewFakeClock(time.Now().Add(24 * time.Hour))
+	a := apptest.NewTestApp(t, app.WithClock(clock))
+	attempts := 0
+	a.RegisterRetryingShutdownHandler(func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, 3, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go advanceWhileWaiting(clock, 10*time.Millisecond, done)
+	err := a.Shutdown(context.Background())
+	close(done)
+	if err != nil {
+		t.Errorf("Shutdown() = %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Errorf("handler ran %d times, want 3", attempts)
+	}
+}
@@ -0,0 +1,697 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownHandler releases resources during the app shutdown.
+// It should return promptly once the context is done.
+type ShutdownHandler func(context.Context) error
+
+// ShutdownOrder defines the order in which shutdown handlers are called.
+type ShutdownOrder int
+
+const (
+	// OrderLIFO calls shutdown handlers in reverse registration order.
+	// Subsystems are usually registered in dependency order (logger, then
+	// database, then HTTP server), so tearing them down in reverse stops
+	// the dependents before what they depend on. This is the default.
+	OrderLIFO ShutdownOrder = iota
+	// OrderFIFO calls shutdown handlers in registration order.
+	OrderFIFO
+)
+
+// HandlerID identifies a registered shutdown handler. Ids start at 1: the
+// zero HandlerID, returned when a registration fails, identifies no handler.
+type HandlerID uint64
+
+// namedShutdownHandler is a registered shutdown handler along with the name
+// used to identify it in logs and its scheduling settings.
+type namedShutdownHandler struct {
+	id       HandlerID
+	name     string
+	fn       ShutdownHandler
+	timeout  time.Duration
+	priority int
+	// namePrefix, when set, replaces "handler" in the auto-generated name.
+	namePrefix string
+	// bestEffort handlers do not contribute to the Shutdown error.
+	bestEffort bool
+	// dependsOn are the names of the handlers that must run after this one.
+	dependsOn []string
+	// cond, when set, tells at shutdown time whether the handler must run.
+	cond func() bool
+	// perCycle handlers were registered once the app started, and are
+	// forgotten when it restarts.
+	perCycle bool
+}
+
+// HandlerOpts configures a shutdown handler registered with
+// RegisterShutdownHandlerOpts.
+type HandlerOpts struct {
+	// Name identifies the handler in the logs. It is auto-generated when empty.
+	Name string
+	// Timeout bounds the handler on its own, see RegisterShutdownHandlerWithTimeout.
+	Timeout time.Duration
+	// Priority orders the handler, see RegisterShutdownHandlerWithPriority.
+	Priority int
+	// Critical makes a failure of the handler count in the error returned by
+	// Shutdown, and thus in the exit code. Failures of non-critical handlers
+	// are only logged and reported.
+	Critical bool
+}
+
+// Shutdown calls all shutdown methods, by ascending priority and then in the
+// order given by ShutdownOrder.
+// When ShutdownTimeout is positive, all handlers share a context bounded by it
+// and no further handlers are called once the deadline is exceeded.
+// That context is created once for the whole sequence and canceled as soon
+// as the deadline passes, so a handler blocked on ctx.Done is unblocked right
+// away instead of being abandoned.
+// A zero ShutdownTimeout means no timeout is applied.
+// When ConcurrentShutdown is set, all handlers are started at once instead
+// and Shutdown waits for them, for at most ShutdownTimeout.
+//
+// A panicking handler does not stop the shutdown: the panic is recovered
+// and counts as the handler error, a *PanicError.
+// Every handler error is logged as it happens and all of them are returned
+// in a *ShutdownError, telling which handlers failed; the result is nil only
+// when all handlers succeeded.
+//
+// Shutdown runs in two phases. The drain handlers registered with
+// RegisterDrainHandler run first, then Shutdown waits for the work tracked
+// with TrackInflight to complete, and only then are the shutdown handlers
+// called. Both phases share the ShutdownTimeout context.
+//
+// Shutdown runs the handlers only once: later calls, concurrent or not,
+// wait for the first one to complete and return its result.
+// With WithPanicOnShutdownError, the first call panics instead of returning
+// a non-nil error. When the shutdown is part of RunAndWait, Run or RunE,
+// they panic instead, once the termination is logged and reported.
+//
+// As a last resort against handlers ignoring their context, a watchdog forces
+// the process to exit, see WithForceExitFunc, if Shutdown is still running
+// once ShutdownTimeout is exceeded by the watchdog margin, see
+// WithWatchdogMargin.
+func (a *App) Shutdown(ctx context.Context) error {
+	ran, err := a.runShutdown(ctx)
+	if ran && err != nil && a.panicOnShutdownError {
+		panic(err)
+	}
+	return err
+}
+
+// runShutdown is Shutdown without the panic of WithPanicOnShutdownError, for
+// the lifecycle to panic only once it terminated. It reports whether this
+// call ran the handlers.
+func (a *App) runShutdown(ctx context.Context) (ran bool, err error) {
+	a.shutdownOnce.Do(func() {
+		ran = true
+		a.setState(StateShuttingDown)
+		a.cancelAppContext(ctx)
+		stopWatchdog := a.startWatchdog()
+		a.shutdownErr = a.shutdown(ctx, false)
+		stopWatchdog()
+		a.flushLogger()
+		a.setState(StateTerminated)
+	})
+	if !ran {
+		a.log().Debug("shutdown already done, returning its result",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.Shutdown"),
+		)
+	}
+	return ran, a.shutdownErr
+}
+
+// startWatchdog forces the process to exit if the shutdown is still running
+// after ShutdownTimeout plus the watchdog margin, which happens when a
+// handler ignores its context. The grace period is already over when
+// Shutdown runs, so it plays no part. It returns the function stopping it.
+// There is no watchdog when ShutdownTimeout or the margin is zero.
+func (a *App) startWatchdog() (stop func()) {
+	if a.ShutdownTimeout <= 0 || a.watchdogMargin <= 0 {
+		return func() {}
+	}
+	deadline := a.ShutdownTimeout + a.watchdogMargin
+	// The shutdown deadline is in real time, whatever the app clock.
+	timer := time.NewTimer(deadline)
+	done := make(chan struct{})
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			a.log().Error("forced exit: shutdown exceeded hard deadline",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.Duration("deadline", deadline),
+			)
+			a.flushLogger()
+			a.forceExit(ExitForced)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// shutdown runs the drain and shutdown handlers, only the ones registered
+// once the app started when cycleOnly is set.
+func (a *App) shutdown(ctx context.Context, cycleOnly bool) error {
+	ctx = withLogger(ctx, a.Logger())
+	// Every handler context derives from this one, so that they are all
+	// canceled at once when the shutdown deadline passes.
+	if a.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.ShutdownTimeout)
+		defer cancel()
+	}
+
+	a.metrics.shutdowns.Add(1)
+	a.recordMetric(MetricShutdownInitiated)
+
+	drainErr := a.drain(ctx, cycleOnly)
+	a.mu.Lock()
+	a.timings = make(map[string]time.Duration)
+	a.shutdownStarted = true
+	a.mu.Unlock()
+	handlers := a.orderedShutdownHandlers()
+	if cycleOnly {
+		handlers = slices.DeleteFunc(handlers, func(h namedShutdownHandler) bool { return !h.perCycle })
+	}
+	if len(handlers) == 0 && !a.quietEmptyShutdown {
+		a.log().Warn("no shutdown handlers registered",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.Shutdown"),
+		)
+	}
+	start := a.now()
+	var err error
+	if a.ConcurrentShutdown {
+		err = a.shutdownConcurrently(ctx, handlers)
+	} else {
+		err = a.shutdownSequentially(ctx, handlers)
+	}
+	a.runSecondPhase(ctx)
+	duration := a.since(start)
+	a.metrics.lastShutdownDuration.Store(int64(duration))
+	a.recordMetric(MetricShutdownFinished)
+	a.log().Info("shutdown handlers finished",
+		slog.Int("handlers", len(handlers)),
+		slog.Duration("duration", duration),
+	)
+	return newShutdownError(errors.Join(drainErr, err))
+}
+
+// ShutdownPlan returns the names of the shutdown handlers in the order
+// Shutdown would call them, honoring priorities, dependencies and
+// ShutdownOrder, without calling anything. It shares the ordering of
+// Shutdown, so that tests can assert the teardown sequence. Conditional
+// handlers are listed even though they may be skipped. With
+// ConcurrentShutdown, all handlers would be started at once.
+func (a *App) ShutdownPlan() []string {
+	handlers := a.orderedShutdownHandlers()
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = h.name
+	}
+	return names
+}
+
+// DryRunShutdown logs the shutdown handlers Shutdown would call, in the
+// order it would call them, without calling them, and returns their names,
+// see ShutdownPlan. It allows checking the shutdown wiring in a --check mode.
+func (a *App) DryRunShutdown(ctx context.Context) []string {
+	names := a.ShutdownPlan()
+	for i, name := range names {
+		a.log().InfoContext(ctx, "dry run: would execute shutdown handler",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.DryRunShutdown"),
+			slog.String("handler", name),
+			slog.Int("position", i+1),
+			slog.Bool("concurrent", a.ConcurrentShutdown),
+		)
+	}
+	return names
+}
+
+// shutdownSequentially runs the shutdown handlers one after the other,
+// stopping once the shutdown context is done. With WithEqualTimeBudget, the
+// handlers without their own timeout get an equal share of the time left.
+func (a *App) shutdownSequentially(ctx context.Context, handlers []namedShutdownHandler) error {
+	var errs []error
+	progress := &shutdownProgress{total: len(handlers)}
+	for i, h := range handlers {
+		if err := a.shutdownContextErr(ctx); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && a.equalTimeBudget && h.timeout <= 0 {
+			// The deadline is in real time, whatever the app clock.
+			h.timeout = time.Until(deadline) / time.Duration(len(handlers)-i)
+		}
+		if err := a.runShutdownHandler(ctx, h, progress); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := a.shutdownContextErr(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownConcurrently runs every shutdown handler in its own goroutine and
+// waits until they all return or the shutdown context is done.
+func (a *App) shutdownConcurrently(ctx context.Context, handlers []namedShutdownHandler) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	progress := &shutdownProgress{total: len(handlers)}
+	for _, h := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.runShutdownHandler(ctx, h, progress); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var ctxErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ctxErr = a.shutdownContextErr(ctx)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(append(errs, ctxErr)...)
+}
+
+// ShutdownTimings returns how long each shutdown handler took during the
+// last shutdown, by handler name, so that slow handlers can be found without
+// a metrics backend. It is reset when a shutdown starts running the handlers.
+// Skipped handlers and handlers of a shutdown abandoned at its deadline are
+// missing.
+func (a *App) ShutdownTimings() map[string]time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return maps.Clone(a.timings)
+}
+
+func (a *App) recordTiming(name string, d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timings == nil {
+		a.timings = make(map[string]time.Duration)
+	}
+	a.timings[name] = d
+}
+
+// shutdownProgress counts the shutdown handlers started and finished, so
+// that operators can tell from the logs where a slow shutdown is.
+type shutdownProgress struct {
+	total    int
+	started  atomic.Int64
+	finished atomic.Int64
+}
+
+// runShutdownHandler calls a single shutdown handler, logs the shutdown
+// progress and how long it took and reports its error. The error is returned
+// only if the handler is critical.
+func (a *App) runShutdownHandler(ctx context.Context, h namedShutdownHandler, progress *shutdownProgress) error {
+	position := progress.started.Add(1)
+	if h.cond != nil && !h.cond() {
+		a.log().Info("shutdown handler skipped",
+			slog.String("handler", h.name),
+			slog.Int64("remaining", int64(progress.total)-progress.finished.Add(1)),
+		)
+		return nil
+	}
+	a.log().Info(fmt.Sprintf("running shutdown handler %s (%d/%d)", h.name, position, progress.total),
+		slog.String("handler", h.name),
+		slog.Int64("position", position),
+		slog.Int("total", progress.total),
+	)
+	start := a.now()
+	err := a.callShutdownHandler(ctx, h)
+	duration := a.since(start)
+	a.recordTiming(h.name, duration)
+	a.log().Info("shutdown handler finished",
+		slog.String("handler", h.name),
+		slog.Duration("duration", duration),
+		slog.Int64("remaining", int64(progress.total)-progress.finished.Add(1)),
+	)
+	if err == nil {
+		return nil
+	}
+	a.metrics.handlerFailures.Add(1)
+	a.recordMetric(MetricHandlerFailed)
+	a.reportError(ctx, err)
+	if h.bestEffort {
+		a.log().Warn("error executing non-critical shutdown handler",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.Shutdown"),
+			slog.String("handler", h.name),
+			slog.String("error", err.Error()),
+		)
+		return nil
+	}
+	a.log().Error("error executing shutdown handler",
+		slog.String("module", "app/app"),
+		slog.String("source", "app.Shutdown"),
+		slog.String("handler", h.name),
+		slog.String("error", err.Error()),
+	)
+	return HandlerError{Name: h.name, Err: err}
+}
+
+// callShutdownHandler calls a single shutdown handler, bounding it by its own
+// timeout when it has one.
+func (a *App) callShutdownHandler(ctx context.Context, h namedShutdownHandler) error {
+	if h.timeout <= 0 {
+		return a.watchCancellation(ctx, h)
+	}
+
+	handlerCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	err := a.watchCancellation(handlerCtx, h)
+	if ctx.Err() == nil && errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+		a.log().Error("shutdown handler timed out",
+			slog.String("module", "app/app"),
+			slog.String("source", "app.Shutdown"),
+			slog.String("handler", h.name),
+			slog.Duration("timeout", h.timeout),
+		)
+		return errors.Join(fmt.Errorf("shutdown handler %q timed out after %s: %w", h.name, h.timeout, context.DeadlineExceeded), err)
+	}
+	return err
+}
+
+// ignoredCancellationThreshold is how long a shutdown handler may keep running
+// after its context is done before it is considered to ignore cancellation.
+const ignoredCancellationThreshold = 100 * time.Millisecond
+
+// watchCancellation calls the handler and logs a warning when it returns well
+// after its context is done, so that handlers defeating the shutdown timeout
+// by ignoring their context can be found.
+func (a *App) watchCancellation(ctx context.Context, h namedShutdownHandler) error {
+	// The context deadline is in real time, whatever the app clock.
+	var doneAt atomic.Pointer[time.Time]
+	stop := context.AfterFunc(ctx, func() {
+		now := time.Now()
+		doneAt.Store(&now)
+	})
+	defer stop()
+
+	err := a.callRecovering(ctx, h)
+	if t := doneAt.Load(); t != nil {
+		if overrun := time.Since(*t); overrun > ignoredCancellationThreshold {
+			a.log().Warn("handler ignored cancellation",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.String("handler", h.name),
+				slog.Duration("overrun", overrun),
+			)
+		}
+	}
+	return err
+}
+
+// callRecovering calls the handler, converting a panic into a *PanicError so
+// that the next handlers still run. The panic is reported along with the
+// other handler errors.
+func (a *App) callRecovering(ctx context.Context, h namedShutdownHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := newPanicError(r)
+			a.log().Error("shutdown handler panicked",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.String("handler", h.name),
+				slog.String("error", perr.Error()),
+				slog.String("stack", string(perr.Stack)),
+			)
+			err = perr
+		}
+	}()
+	return h.fn(ctx)
+}
+
+// RunWithContext calls fn, which does not support cancellation, and returns
+// its error, or the context error if ctx is done first. In that case fn keeps
+// running in the background and its result is discarded. It lets shutdown
+// handlers wrap blocking calls without defeating the shutdown timeout.
+func RunWithContext(ctx context.Context, fn func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- fn()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// orderedShutdownHandlers returns a snapshot of the registered shutdown
+// handlers, in the order they must be called.
+// Working on a snapshot lets handlers register other handlers without deadlocking.
+func (a *App) orderedShutdownHandlers() []namedShutdownHandler {
+	a.mu.Lock()
+	handlers := append([]namedShutdownHandler(nil), a.shutdownHandlers...)
+	a.mu.Unlock()
+
+	if a.ShutdownOrder == OrderLIFO {
+		slices.Reverse(handlers)
+	}
+	slices.SortStableFunc(handlers, func(x, y namedShutdownHandler) int {
+		return cmp.Compare(x.priority, y.priority)
+	})
+	return a.orderByDependencies(handlers)
+}
+
+// shutdownContextErr reports why the shutdown context is done, if it is.
+func (a *App) shutdownContextErr(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) && a.ShutdownTimeout > 0 {
+		return fmt.Errorf("shutdown timed out after %s: %w", a.ShutdownTimeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// RegisterShutdownHandler adds a handler to be called during Shutdown.
+// The handler is given an auto-generated name like "handler-1".
+// The returned id can be used to deregister it.
+//
+// Handlers registered while Shutdown runs the handlers, typically by the
+// handlers themselves, are run in a second phase, once the current handlers
+// returned, for what is left of ShutdownTimeout. This is best effort: they
+// are all non-critical and run one after the other, in registration order.
+// Handlers registered once Shutdown completed would never be called: this is
+// logged as a warning and they are run right away instead, as non-critical
+// handlers bounded by ShutdownTimeout. This applies to all the registration
+// methods.
+func (a *App) RegisterShutdownHandler(handler ShutdownHandler) HandlerID {
+	return a.RegisterNamedShutdownHandler("", handler)
+}
+
+// RegisterNamedShutdownHandler adds a handler to be called during Shutdown,
+// identified by name in the shutdown logs.
+// An empty name is replaced by an auto-generated one.
+func (a *App) RegisterNamedShutdownHandler(name string, handler ShutdownHandler) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{name: name, fn: handler})
+}
+
+// RegisterCleanup adds a cleanup function that does not need a context,
+// such as db.Close, to be called during Shutdown.
+func (a *App) RegisterCleanup(cleanup func() error) HandlerID {
+	return a.RegisterShutdownHandler(func(context.Context) error {
+		return cleanup()
+	})
+}
+
+// RegisterCloser adds c to be closed during Shutdown. The error returned by
+// Close is part of the error returned by Shutdown. The handler is named
+// after the type of c and its id in the shutdown logs, like "*os.File-3".
+func (a *App) RegisterCloser(c io.Closer) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{
+		namePrefix: fmt.Sprintf("%T", c),
+		fn: func(context.Context) error {
+			return c.Close()
+		},
+	})
+}
+
+// RegisterConditionalShutdownHandler adds a handler to be called during
+// Shutdown only if cond, evaluated right before the handler would run,
+// returns true. Otherwise the handler is logged as skipped.
+func (a *App) RegisterConditionalShutdownHandler(cond func() bool, handler ShutdownHandler) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{fn: handler, cond: cond})
+}
+
+// RegisterShutdownHandlerWithTimeout adds a handler to be called during Shutdown
+// with its own timeout. If the handler exceeds it, the timeout is logged and
+// shutdown moves on to the next handler. ShutdownTimeout still caps the
+// whole shutdown sequence.
+func (a *App) RegisterShutdownHandlerWithTimeout(handler ShutdownHandler, timeout time.Duration) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{fn: handler, timeout: timeout})
+}
+
+// RegisterShutdownHandlerWithPriority adds a handler to be called during
+// Shutdown according to its priority: handlers with a lower priority run
+// first, regardless of when they were registered. Handlers sharing a priority
+// run in the order given by ShutdownOrder. The handlers registered without
+// a priority have priority 0.
+func (a *App) RegisterShutdownHandlerWithPriority(priority int, handler ShutdownHandler) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{fn: handler, priority: priority})
+}
+
+// RegisterShutdownHandlerOpts adds a handler to be called during Shutdown,
+// configured by opts. Unlike the other registration methods, which register
+// critical handlers, the handler is only critical if opts.Critical is set.
+func (a *App) RegisterShutdownHandlerOpts(handler ShutdownHandler, opts HandlerOpts) HandlerID {
+	return a.registerShutdownHandler(namedShutdownHandler{
+		name:       opts.Name,
+		fn:         handler,
+		timeout:    opts.Timeout,
+		priority:   opts.Priority,
+		bestEffort: !opts.Critical,
+	})
+}
+
+// DeregisterShutdownHandler removes a previously registered shutdown handler.
+// It returns false if no handler is registered with the given id.
+func (a *App) DeregisterShutdownHandler(id HandlerID) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, h := range a.shutdownHandlers {
+		if h.id == id {
+			a.shutdownHandlers = slices.Delete(a.shutdownHandlers, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// HandlerNames returns the names of the registered shutdown handlers,
+// in registration order.
+func (a *App) HandlerNames() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	names := make([]string, len(a.shutdownHandlers))
+	for i, h := range a.shutdownHandlers {
+		names[i] = h.name
+	}
+	return names
+}
+
+// registerShutdownHandler adds h to the shutdown handlers. Once Shutdown
+// completed, h can no longer be scheduled and is run right away instead.
+func (a *App) registerShutdownHandler(h namedShutdownHandler) HandlerID {
+	a.mu.Lock()
+	h, late := a.addShutdownHandlerLocked(h)
+	a.mu.Unlock()
+	if late {
+		a.runLateShutdownHandler(h)
+	}
+	return h.id
+}
+
+// addShutdownHandlerLocked assigns h its id and default name and adds it to
+// the shutdown handlers, or to the second phase ones while Shutdown runs the
+// handlers. Once Shutdown completed, it reports h as late instead.
+// a.mu must be held.
+func (a *App) addShutdownHandlerLocked(h namedShutdownHandler) (namedShutdownHandler, bool) {
+	a.lastHandlerID++
+	h.id = a.lastHandlerID
+	h.perCycle = a.cycleStarted
+	if h.name == "" {
+		prefix := h.namePrefix
+		if prefix == "" {
+			prefix = "handler"
+		}
+		h.name = fmt.Sprintf("%s-%d", prefix, h.id)
+	}
+	if a.shutdownFinished {
+		return h, true
+	}
+	if a.shutdownStarted {
+		h.bestEffort = true
+		a.secondPhaseHandlers = append(a.secondPhaseHandlers, h)
+		return h, false
+	}
+	a.shutdownHandlers = append(a.shutdownHandlers, h)
+	return h, false
+}
+
+// runSecondPhase runs the handlers registered while the shutdown handlers
+// ran, including the ones they register in turn, until there are none left.
+// Their errors are only logged.
+func (a *App) runSecondPhase(ctx context.Context) {
+	for {
+		a.mu.Lock()
+		handlers := a.secondPhaseHandlers
+		a.secondPhaseHandlers = nil
+		if len(handlers) == 0 {
+			a.shutdownFinished = true
+			a.mu.Unlock()
+			return
+		}
+		a.mu.Unlock()
+
+		a.log().Info("running shutdown handlers registered during shutdown",
+			slog.Int("handlers", len(handlers)),
+		)
+		if err := a.shutdownSequentially(ctx, handlers); err != nil {
+			a.log().Warn("second phase of the shutdown incomplete",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Shutdown"),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// runLateShutdownHandler runs a handler registered after Shutdown completed,
+// which would otherwise never run. It is run as a non-critical handler,
+// bounded by ShutdownTimeout.
+func (a *App) runLateShutdownHandler(h namedShutdownHandler) {
+	a.log().Warn("shutdown handler registered after shutdown completed, running it now",
+		slog.String("module", "app/app"),
+		slog.String("source", "app.Shutdown"),
+		slog.String("handler", h.name),
+	)
+	ctx := withLogger(context.Background(), a.Logger())
+	if a.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.ShutdownTimeout)
+		defer cancel()
+	}
+	h.bestEffort = true
+	_ = a.runShutdownHandler(ctx, h, &shutdownProgress{total: 1})
+}
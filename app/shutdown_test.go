@@ -0,0 +1,380 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestShutdownTimeout(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(50*time.Millisecond))
+	var laterCalled atomic.Bool
+	// Registered first, so called last.
+	a.RegisterShutdownHandler(func(context.Context) error {
+		laterCalled.Store(true)
+		return nil
+	})
+	a.RegisterShutdownHandler(func(context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	err := a.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() = %v, want a context.DeadlineExceeded error", err)
+	}
+	if !strings.Contains(err.Error(), "shutdown timed out after 50ms") {
+		t.Errorf("Shutdown() = %q, want it to tell the timeout", err)
+	}
+	if laterCalled.Load() {
+		t.Error("a handler was called after the deadline")
+	}
+}
+
+func TestShutdownDeadlineCancelsHandlerContext(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(50*time.Millisecond))
+	var ctxErr error
+	a.RegisterShutdownHandler(func(ctx context.Context) error {
+		<-ctx.Done()
+		ctxErr = ctx.Err()
+		return ctxErr
+	})
+
+	// The deadline is set before the handler is called: measure from there.
+	start := time.Now()
+	a.Shutdown(context.Background())
+	elapsed := time.Since(start)
+	if ctxErr != context.DeadlineExceeded {
+		t.Errorf("handler context error = %v, want context.DeadlineExceeded", ctxErr)
+	}
+	if elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Shutdown returned after %s, want at the 50ms deadline", elapsed)
+	}
+}
+
+func TestShutdownWithoutTimeout(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(0))
+	a.RegisterShutdownHandler(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("handler context has a deadline with a zero ShutdownTimeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	apptest.Shutdown(t, a)
+}
+
+// recordingHandler returns a shutdown handler appending name to calls.
+func recordingHandler(calls *[]string, name string) app.ShutdownHandler {
+	return func(context.Context) error {
+		*calls = append(*calls, name)
+		return nil
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		order app.ShutdownOrder
+		want  []string
+	}{
+		{"LIFO", app.OrderLIFO, []string{"http", "db", "logger"}},
+		{"FIFO", app.OrderFIFO, []string{"logger", "db", "http"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := apptest.NewTestApp(t, app.WithShutdownOrder(tt.order))
+			var calls []string
+			for _, name := range []string{"logger", "db", "http"} {
+				a.RegisterShutdownHandler(recordingHandler(&calls, name))
+			}
+
+			apptest.Shutdown(t, a)
+			if !slices.Equal(calls, tt.want) {
+				t.Errorf("handlers called in order %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestShutdownOrderDefaultsToLIFO(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var calls []string
+	a.RegisterShutdownHandler(recordingHandler(&calls, "first"))
+	a.RegisterShutdownHandler(recordingHandler(&calls, "second"))
+
+	apptest.Shutdown(t, a)
+	if want := []string{"second", "first"}; !slices.Equal(calls, want) {
+		t.Errorf("handlers called in order %v, want %v", calls, want)
+	}
+}
+
+func TestShutdownPriorities(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownOrder(app.OrderFIFO))
+	var calls []string
+	a.RegisterShutdownHandlerWithPriority(10, recordingHandler(&calls, "flush metrics"))
+	a.RegisterShutdownHandlerWithPriority(0, recordingHandler(&calls, "stop http"))
+	a.RegisterShutdownHandlerWithPriority(5, recordingHandler(&calls, "close db"))
+	a.RegisterShutdownHandlerWithPriority(0, recordingHandler(&calls, "stop grpc"))
+	a.RegisterShutdownHandlerWithPriority(10, recordingHandler(&calls, "flush logs"))
+
+	apptest.Shutdown(t, a)
+	want := []string{"stop http", "stop grpc", "close db", "flush metrics", "flush logs"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("handlers called in order %v, want %v", calls, want)
+	}
+}
+
+func TestConditionalShutdownHandler(t *testing.T) {
+	for _, initialized := range []bool{true, false} {
+		t.Run(fmt.Sprintf("initialized=%t", initialized), func(t *testing.T) {
+			a := apptest.NewTestApp(t)
+			ran := false
+			a.RegisterConditionalShutdownHandler(func() bool { return initialized }, func(context.Context) error {
+				ran = true
+				return nil
+			})
+
+			apptest.Shutdown(t, a)
+			if ran != initialized {
+				t.Errorf("handler ran = %t, want %t", ran, initialized)
+			}
+		})
+	}
+}
+
+// closerFunc is an io.Closer calling itself.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestClosersOfTheSameTypeHaveDistinctNames(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	closed := 0
+	for range 2 {
+		a.RegisterCloser(closerFunc(func() error {
+			closed++
+			return nil
+		}))
+	}
+
+	names := a.HandlerNames()
+	if len(names) != 2 || names[0] == names[1] {
+		t.Errorf("HandlerNames() = %v, want two distinct names", names)
+	}
+	apptest.Shutdown(t, a)
+	if closed != 2 {
+		t.Errorf("%d closers closed, want 2", closed)
+	}
+	if timings := a.ShutdownTimings(); len(timings) != 2 {
+		t.Errorf("ShutdownTimings() = %v, want one timing per closer", timings)
+	}
+}
+
+func TestShutdownHandlerDependencies(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownOrder(app.OrderFIFO))
+	var calls []string
+	mustRegister := func(name string, dependsOn ...string) {
+		t.Helper()
+		if _, err := a.RegisterShutdownHandlerDep(name, dependsOn, recordingHandler(&calls, name)); err != nil {
+			t.Fatalf("RegisterShutdownHandlerDep(%q) = %v, want nil", name, err)
+		}
+	}
+	mustRegister("db")
+	mustRegister("http", "db")
+
+	if _, err := a.RegisterShutdownHandlerDep("self", []string{"self"}, recordingHandler(&calls, "self")); err == nil {
+		t.Error("RegisterShutdownHandlerDep() = nil for a handler depending on itself, want a cycle error")
+	}
+
+	apptest.Shutdown(t, a)
+	if want := []string{"http", "db"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestFailedRegistrationIdentifiesNoHandler(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var calls []string
+	a.RegisterNamedShutdownHandler("first", recordingHandler(&calls, "first"))
+	id, err := a.RegisterShutdownHandlerDep("", nil, recordingHandler(&calls, "unnamed"))
+	if err == nil {
+		t.Fatal("RegisterShutdownHandlerDep() = nil for an unnamed handler, want an error")
+	}
+	if a.DeregisterShutdownHandler(id) {
+		t.Error("DeregisterShutdownHandler() = true for the id of a failed registration")
+	}
+
+	apptest.Shutdown(t, a)
+	if want := []string{"first"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestPanickingShutdownHandlerDoesNotStopTheOthers(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownOrder(app.OrderFIFO))
+	var reported []error
+	a.RegisterErrorReporter(func(_ context.Context, err error) {
+		reported = append(reported, err)
+	})
+	var calls []string
+	a.RegisterNamedShutdownHandler("panicking", func(context.Context) error {
+		panic("boom")
+	})
+	a.RegisterShutdownHandler(recordingHandler(&calls, "next"))
+
+	err := a.Shutdown(context.Background())
+	var perr *app.PanicError
+	if !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("Shutdown() = %v, want the recovered *PanicError", err)
+	}
+	if !slices.Equal(calls, []string{"next"}) {
+		t.Errorf("calls = %v, want the handler after the panicking one to run", calls)
+	}
+	if len(reported) == 0 || !errors.As(reported[0], &perr) {
+		t.Errorf("reported errors = %v, want the panic", reported)
+	}
+}
+
+func TestConcurrentShutdownCallsRunHandlersOnce(t *testing.T) {
+	errClose := errors.New("close failed")
+	a := apptest.NewTestApp(t)
+	var calls atomic.Int32
+	a.RegisterShutdownHandler(func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return errClose
+	})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = a.Shutdown(context.Background())
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, errClose) {
+			t.Errorf("Shutdown call %d = %v, want the error of the first call", i, err)
+		}
+	}
+}
+
+func TestWatchdogSparesHandlersHonoringTheirContext(t *testing.T) {
+	var forced atomic.Bool
+	a := apptest.NewTestApp(t,
+		app.WithShutdownTimeout(100*time.Millisecond),
+		app.WithForceExitFunc(func(int) { forced.Store(true) }),
+	)
+	a.RegisterShutdownHandler(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := a.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want a context.DeadlineExceeded error", err)
+	}
+	if forced.Load() {
+		t.Error("the watchdog forced an exit although the handler honored its context")
+	}
+}
+
+func TestWatchdogForcesExitWhenHandlerIgnoresItsContext(t *testing.T) {
+	forced := make(chan int, 1)
+	a := apptest.NewTestApp(t,
+		app.WithShutdownTimeout(50*time.Millisecond),
+		app.WithWatchdogMargin(50*time.Millisecond),
+		app.WithForceExitFunc(func(code int) { forced <- code }),
+	)
+	release := make(chan struct{})
+	a.RegisterShutdownHandler(func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		a.Shutdown(context.Background())
+		close(done)
+	}()
+	select {
+	case code := <-forced:
+		if code != app.ExitForced {
+			t.Errorf("forced exit code = %d, want %d", code, app.ExitForced)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the watchdog did not force an exit")
+	}
+	close(release)
+	<-done
+}
+
+func TestEqualTimeBudgetWithFakeClock(t *testing.T) {
+	for name, offset := range map[string]time.Duration{
+		"ahead":  24 * time.Hour,
+		"behind": -24 * time.Hour,
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := apptest.NewTestApp(t,
+				app.WithClock(apptest.NewFakeClock(time.Now().Add(offset))),
+				app.WithEqualTimeBudget(),
+			)
+			var budgets []time.Duration
+			for range 2 {
+				a.RegisterShutdownHandler(func(ctx context.Context) error {
+					deadline, _ := ctx.Deadline()
+					budgets = append(budgets, time.Until(deadline))
+					return nil
+				})
+			}
+
+			apptest.Shutdown(t, a)
+			if len(budgets) != 2 {
+				t.Fatalf("%d handlers ran, want 2", len(budgets))
+			}
+			if budgets[0] > apptest.ShutdownTimeout/2 {
+				t.Errorf("first handler budget = %s, want at most half of %s", budgets[0], apptest.ShutdownTimeout)
+			}
+		})
+	}
+}
+
+func TestWatchdogIgnoresTheAppClock(t *testing.T) {
+	clock := apptest.NewFakeClock(time.Now())
+	var forced atomic.Bool
+	a := apptest.NewTestApp(t,
+		app.WithClock(clock),
+		app.WithForceExitFunc(func(int) { forced.Store(true) }),
+	)
+	a.RegisterShutdownHandler(func(context.Context) error {
+		// Lets the watchdog goroutine start waiting.
+		time.Sleep(20 * time.Millisecond)
+		if n := clock.Waiters(); n != 0 {
+			t.Errorf("FakeClock.Waiters() = %d during the shutdown, want 0", n)
+		}
+		clock.Advance(time.Hour)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	apptest.Shutdown(t, a)
+	if forced.Load() {
+		t.Error("advancing the app clock made the watchdog force an exit")
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalAction is what the app does when it receives a signal.
+type SignalAction int
+
+const (
+	// ActionGracefulShutdown shuts the app down gracefully. It is the action
+	// of the Signals, SIGINT and SIGTERM by default.
+	ActionGracefulShutdown SignalAction = iota
+	// ActionReload calls the reload handlers. It is the action of SIGHUP by
+	// default.
+	ActionReload
+	// ActionForceExit exits right away, without calling the shutdown
+	// handlers, with the ExitForced code.
+	ActionForceExit
+	// ActionIgnore ignores the signal.
+	ActionIgnore
+	// ActionTogglePause pauses the app, or resumes it when it is paused,
+	// see Pause. SIGUSR1 is the usual choice.
+	ActionTogglePause
+)
+
+func (s SignalAction) String() string {
+	switch s {
+	case ActionGracefulShutdown:
+		return "graceful_shutdown"
+	case ActionReload:
+		return "reload"
+	case ActionForceExit:
+		return "force_exit"
+	case ActionIgnore:
+		return "ignore"
+	case ActionTogglePause:
+		return "toggle_pause"
+	default:
+		return "unknown"
+	}
+}
+
+// signalMapping is a signal action set with WithSignalAction.
+type signalMapping struct {
+	sig    os.Signal
+	action SignalAction
+}
+
+// signalNames are the conventional names of the usual shutdown signals.
+var signalNames = map[os.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// SignalName returns the conventional name of sig, such as "SIGTERM", which
+// operators recognize more easily than its description, "terminated".
+// Signals without a known name are described by their String method.
+func SignalName(sig os.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return sig.String()
+}
+
+// validateSignalActions panics when a signal is given several actions with
+// WithSignalAction, or is also the re-exec signal.
+func (a *App) validateSignalActions() {
+	seen := make(map[os.Signal]SignalAction)
+	for _, m := range a.signalMappings {
+		if prev, ok := seen[m.sig]; ok && prev != m.action {
+			panic(fmt.Sprintf("app: signal %s is mapped to both %s and %s", SignalName(m.sig), prev, m.action))
+		}
+		if m.sig == a.reExecSignal {
+			panic(fmt.Sprintf("app: signal %s is both the re-exec signal and mapped to %s", SignalName(m.sig), m.action))
+		}
+		seen[m.sig] = m.action
+	}
+}
+
+// signalActions returns the action of every handled signal: the Signals shut
+// the app down gracefully and SIGHUP reloads it, unless WithSignalAction
+// says otherwise.
+func (a *App) signalActions() map[os.Signal]SignalAction {
+	actions := map[os.Signal]SignalAction{syscall.SIGHUP: ActionReload}
+	for _, sig := range a.Signals {
+		actions[sig] = ActionGracefulShutdown
+	}
+	for _, m := range a.signalMappings {
+		actions[m.sig] = m.action
+	}
+	return actions
+}
+
+// signalsWith returns the signals mapped to action.
+func signalsWith(actions map[os.Signal]SignalAction, action SignalAction) []os.Signal {
+	var sigs []os.Signal
+	for sig, a := range actions {
+		if a == action {
+			sigs = append(sigs, sig)
+		}
+	}
+	return sigs
+}
+
+// notifySignals relays sigs to c and returns the function stopping it.
+// signal.Notify relays every signal when given none, so an empty list must
+// not be passed to it.
+func notifySignals(c chan<- os.Signal, sigs []os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+	signal.Notify(c, sigs...)
+	return func() {
+		signal.Stop(c)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// StartupHandler initializes a subsystem before the main loop starts.
+type StartupHandler func(context.Context) error
+
+// RegisterStartupHandler adds a handler to be called, in registration order,
+// right before the main loop starts. If a startup handler fails, or if they
+// take more than StartupTimeout together, the remaining ones are skipped,
+// the main loop is not started and the shutdown handlers registered so far
+// are called.
+func (a *App) RegisterStartupHandler(handler StartupHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.startupHandlers = append(a.startupHandlers, handler)
+}
+
+// RegisterStartupHandlerWithRetry adds a startup handler, see
+// RegisterStartupHandler, that is retried on error, up to attempts times in
+// total, for dependencies such as databases that may not be ready yet when
+// the app starts. The wait between two attempts starts at backoff and doubles
+// after each attempt. Every failed attempt is logged and the handler is never
+// retried past StartupTimeout.
+func (a *App) RegisterStartupHandlerWithRetry(handler StartupHandler, attempts int, backoff time.Duration) {
+	a.RegisterStartupHandler(a.retrying(handler, attempts, backoff))
+}
+
+// runStartupHandlers calls the startup handlers in registration order,
+// stopping at the first failure.
+func (a *App) runStartupHandlers(ctx context.Context) error {
+	a.mu.Lock()
+	handlers := append([]StartupHandler(nil), a.startupHandlers...)
+	a.mu.Unlock()
+
+	if a.StartupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.StartupTimeout)
+		defer cancel()
+	}
+
+	for i, handler := range handlers {
+		err := handler(ctx)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && a.StartupTimeout > 0 {
+			a.log().Error("startup timed out",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Run"),
+				slog.Int("index", i),
+				slog.Duration("timeout", a.StartupTimeout),
+			)
+			return errors.Join(fmt.Errorf("startup timed out after %s: %w", a.StartupTimeout, context.DeadlineExceeded), err)
+		}
+		if err != nil {
+			a.log().Error("error executing startup handler",
+				slog.String("module", "app/app"),
+				slog.String("source", "app.Run"),
+				slog.Int("index", i),
+				slog.String("error", err.Error()),
+			)
+			return fmt.Errorf("startup handler %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestStartupHandlersRunBeforeMainLoop(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var calls []string
+	for _, name := range []string{"db", "cache"} {
+		a.RegisterStartupHandler(func(context.Context) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+
+	err := a.RunE(context.Background(), func(context.Context) error {
+		calls = append(calls, "main loop")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if want := []string{"db", "cache", "main loop"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestFailingStartupAbortsCleanly(t *testing.T) {
+	errConnect := errors.New("connect failed")
+	a := apptest.NewTestApp(t)
+	var calls []string
+	a.RegisterStartupHandler(func(context.Context) error {
+		a.RegisterShutdownHandler(func(context.Context) error {
+			calls = append(calls, "close db")
+			return nil
+		})
+		return nil
+	})
+	a.RegisterStartupHandler(func(context.Context) error { return errConnect })
+	a.RegisterStartupHandler(func(context.Context) error {
+		calls = append(calls, "skipped startup")
+		return nil
+	})
+
+	code := a.Run(func(context.Context) error {
+		calls = append(calls, "main loop")
+		return nil
+	})
+	if code != app.ExitStartupError {
+		t.Errorf("Run() = %d, want ExitStartupError", code)
+	}
+	if want := []string{"close db"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want only the shutdown of what started, %v", calls, want)
+	}
+}
+
+func TestRunEReturnsStartupError(t *testing.T) {
+	errConnect := errors.New("connect failed")
+	a := apptest.NewTestApp(t)
+	a.RegisterStartupHandler(func(context.Context) error { return errConnect })
+
+	if err := a.RunE(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, errConnect) {
+		t.Errorf("RunE() = %v, want the startup error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+// AppState is a stage of the app lifecycle.
+type AppState int32
+
+const (
+	// StateNew is the state of an app that has not been run yet.
+	StateNew AppState = iota
+	// StateStarting means the startup handlers are running.
+	StateStarting
+	// StateRunning means the main loop is running.
+	StateRunning
+	// StateShuttingDown means the app is in its grace period or running its
+	// shutdown handlers.
+	StateShuttingDown
+	// StateTerminated means the shutdown handlers have all been called.
+	StateTerminated
+)
+
+func (s AppState) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateShuttingDown:
+		return "shutting_down"
+	case StateTerminated:
+		return "terminated"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current lifecycle state of the app.
+func (a *App) State() AppState {
+	return AppState(a.state.Load())
+}
+
+func (a *App) setState(s AppState) {
+	if AppState(a.state.Swap(int32(s))) != s {
+		a.recordMetric(MetricStateChanged)
+	}
+}
+
+// Done returns a channel that is closed once the app terminated, after
+// RunAndWait called all the shutdown handlers.
+func (a *App) Done() <-chan struct{} {
+	return a.doneChan()
+}
+
+// doneChan lazily creates the Done channel.
+func (a *App) doneChan() chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.done == nil {
+		a.done = make(chan struct{})
+	}
+	return a.done
+}
+
+// markDone closes the Done channel, exactly once.
+func (a *App) markDone() {
+	done := a.doneChan()
+	a.doneOnce.Do(func() {
+		close(done)
+	})
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TickerOption configures a loop built by TickerLoop.
+type TickerOption func(*tickerConfig)
+
+type tickerConfig struct {
+	clock           Clock
+	continueOnError bool
+}
+
+// WithTickerClock sets the clock the loop waits on, for driving it with
+// apptest.FakeClock in tests.
+func WithTickerClock(clock Clock) TickerOption {
+	return func(c *tickerConfig) {
+		c.clock = clock
+	}
+}
+
+// WithTickerContinueOnError makes the loop log the errors of its function
+// and keep ticking instead of returning them.
+func WithTickerContinueOnError() TickerOption {
+	return func(c *tickerConfig) {
+		c.continueOnError = true
+	}
+}
+
+// TickerLoop returns a main loop calling fn every interval until its context
+// is done, then returning nil. The first call happens after the first
+// interval. By default, the first error returned by fn stops the loop and is
+// returned as the main loop error.
+func TickerLoop(interval time.Duration, fn func(context.Context) error, opts ...TickerOption) MainLoopFunc {
+	config := tickerConfig{clock: realClock{}}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(ctx context.Context) error {
+		for {
+			select {
+			case <-config.clock.After(interval):
+			case <-ctx.Done():
+				return nil
+			}
+
+			if err := fn(ctx); err != nil {
+				if !config.continueOnError {
+					return err
+				}
+				LoggerFromContext(ctx).Error("ticker loop iteration failed",
+					slog.String("module", "app/ticker"),
+					slog.String("source", "app.TickerLoop"),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
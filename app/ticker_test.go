@@ -0,0 +1,87 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+// tick advances clock by d once the loop under test waits on it.
+func tick(clock *apptest.FakeClock, d time.Duration) {
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(d)
+}
+
+func TestTickerLoop(t *testing.T) {
+	clock := apptest.NewFakeClock(time.Now())
+	calls := make(chan struct{})
+	loop := app.TickerLoop(time.Minute, func(context.Context) error {
+		calls <- struct{}{}
+		return nil
+	}, app.WithTickerClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- loop(ctx) }()
+	for range 3 {
+		tick(clock, time.Minute)
+		<-calls
+	}
+	cancel()
+	if err := <-errs; err != nil {
+		t.Errorf("loop returned %v once canceled, want nil", err)
+	}
+}
+
+func TestTickerLoopReturnsError(t *testing.T) {
+	clock := apptest.NewFakeClock(time.Now())
+	errTick := errors.New("tick failed")
+	loop := app.TickerLoop(time.Minute, func(context.Context) error {
+		return errTick
+	}, app.WithTickerClock(clock))
+
+	errs := make(chan error, 1)
+	go func() { errs <- loop(context.Background()) }()
+	tick(clock, time.Minute)
+	if err := <-errs; !errors.Is(err, errTick) {
+		t.Errorf("loop returned %v, want the tick error", err)
+	}
+}
+
+func TestTickerLoopContinueOnError(t *testing.T) {
+	var buf syncBuffer
+	a := apptest.NewTestApp(t, app.WithLogOutput(&buf))
+	clock := apptest.NewFakeClock(time.Now())
+	calls := make(chan struct{})
+	loop := app.TickerLoop(time.Minute, func(context.Context) error {
+		calls <- struct{}{}
+		return errors.New("tick failed")
+	}, app.WithTickerClock(clock), app.WithTickerContinueOnError())
+
+	// RunE does not wait for the canceled main loop: its error is passed on.
+	loopErrs := make(chan error, 1)
+	go a.RunE(context.Background(), func(ctx context.Context) error {
+		err := loop(ctx)
+		loopErrs <- err
+		return err
+	})
+	for range 2 {
+		tick(clock, time.Minute)
+		<-calls
+	}
+	a.TriggerShutdown("test")
+	if err := <-loopErrs; err != nil {
+		t.Errorf("loop returned %v, want it to keep ticking and return nil once canceled", err)
+	}
+	// The errors are logged with the app logger the loop context carries.
+	if out := buf.String(); !strings.Contains(out, "ticker loop iteration failed") {
+		t.Errorf("the app logs miss the failed iterations:\n%s", out)
+	}
+}
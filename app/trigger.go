@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotRunning is returned by Restart when the app is not running.
+var ErrNotRunning = errors.New("app is not running")
+
+// TriggerShutdown makes the running app shut down as if a shutdown signal was
+// received: the grace period elapses and then the shutdown handlers are called.
+// The reason is recorded in the logs. It is safe to call multiple times and
+// from any goroutine; only the first pending call has an effect.
+func (a *App) TriggerShutdown(reason string) {
+	select {
+	case a.triggerChan() <- reason:
+	default:
+	}
+}
+
+// TriggerShutdownAndWait triggers the shutdown like TriggerShutdown and then
+// blocks until the app terminated, returning the error of the shutdown
+// handlers, or until ctx is done, returning its error. When the app is
+// already shutting down, it only waits for that shutdown to complete.
+func (a *App) TriggerShutdownAndWait(ctx context.Context, reason string) error {
+	a.TriggerShutdown(reason)
+	select {
+	case <-a.Done():
+		// Shutdown completed before Done was closed.
+		return a.shutdownErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// triggerChan lazily creates the channel TriggerShutdown sends reasons on.
+func (a *App) triggerChan() chan string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.trigger == nil {
+		a.trigger = make(chan string, 1)
+	}
+	return a.trigger
+}
+
+// Restart restarts the running app in process, for changes a reload cannot
+// apply: the main loop context is canceled and, once the main loop returned,
+// the drain and shutdown handlers registered once the app started, by the
+// startup handlers or the main loop, are called, with a CauseRestart shutdown
+// cause, without grace period. They are then forgotten and the startup
+// handlers run again, so that they register the handlers of the new cycle,
+// before the main loop is launched again. The handlers registered before Run
+// are only called on the final shutdown.
+// If a startup handler fails, or if the main loop does not return within
+// ShutdownTimeout, the app shuts down.
+//
+// Restart returns right away. It returns ErrNotRunning if the app is not
+// running, for instance while it is shutting down, and does nothing if a
+// restart is already pending.
+func (a *App) Restart() error {
+	if a.State() != StateRunning {
+		return ErrNotRunning
+	}
+	select {
+	case a.restartChan() <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// restartChan lazily creates the channel Restart sends requests on.
+func (a *App) restartChan() chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.restarts == nil {
+		a.restarts = make(chan struct{}, 1)
+	}
+	return a.restarts
+}
@@ -0,0 +1,118 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestRestart(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var startups, shutdowns, loops atomic.Int32
+	a.RegisterStartupHandler(func(context.Context) error {
+		startups.Add(1)
+		a.RegisterShutdownHandler(func(context.Context) error {
+			shutdowns.Add(1)
+			return nil
+		})
+		return nil
+	})
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		if loops.Add(1) == 1 {
+			if err := a.Restart(); err != nil {
+				t.Errorf("Restart() = %v, want nil", err)
+			}
+		} else {
+			a.TriggerShutdown("test")
+		}
+		<-ctx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	if got := loops.Load(); got != 2 {
+		t.Errorf("main loop launched %d times, want 2", got)
+	}
+	if got := startups.Load(); got != 2 {
+		t.Errorf("startup handler ran %d times, want 2", got)
+	}
+	// The handler of each cycle runs once: the first one is forgotten after
+	// the restart instead of running again at the final shutdown.
+	if got := shutdowns.Load(); got != 2 {
+		t.Errorf("shutdown handlers ran %d times, want 2", got)
+	}
+}
+
+func TestRestartKeepsHandlersRegisteredBeforeRun(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	var drains, probes, loops atomic.Int32
+	a.RegisterDrainHandler(func(context.Context) error {
+		drains.Add(1)
+		return nil
+	})
+	a.RegisterNamedShutdownHandler("probe", func(context.Context) error {
+		probes.Add(1)
+		return nil
+	})
+
+	err := a.RunE(context.Background(), func(ctx context.Context) error {
+		if loops.Add(1) == 1 {
+			a.Restart()
+		} else {
+			if got := probes.Load(); got != 0 {
+				t.Errorf("probe handler ran %d times on restart, want 0", got)
+			}
+			a.TriggerShutdown("test")
+		}
+		<-ctx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("RunE() = %v, want nil", err)
+	}
+	// The handlers registered before Run serve every cycle: they only run at
+	// the final shutdown.
+	if got := drains.Load(); got != 1 {
+		t.Errorf("drain handler ran %d times, want 1", got)
+	}
+	if got := probes.Load(); got != 1 {
+		t.Errorf("probe handler ran %d times, want 1", got)
+	}
+}
+
+func TestRestartWhenNotRunning(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	if err := a.Restart(); !errors.Is(err, app.ErrNotRunning) {
+		t.Errorf("Restart() = %v before running, want ErrNotRunning", err)
+	}
+}
+
+func TestRestartWithLoopIgnoringItsContext(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(50*time.Millisecond))
+	release := make(chan struct{})
+	defer close(release)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- a.RunE(context.Background(), func(context.Context) error {
+			a.Restart()
+			<-release
+			return nil
+		})
+	}()
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("RunE() = nil, want the error of the main loop ignoring the restart")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the restart hung on a main loop ignoring its context")
+	}
+}
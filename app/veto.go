@@ -0,0 +1,34 @@
+package app
+
+import "context"
+
+// ShutdownVeto is asked whether a shutdown signal may shut the app down.
+// It returns false to veto the shutdown, for instance because of unsaved work.
+type ShutdownVeto func(context.Context) bool
+
+// RegisterShutdownVeto adds a veto consulted when a shutdown signal is
+// received. If any veto returns false, the app keeps running. Vetoes only
+// apply to signals, never to TriggerShutdown, context cancellation or the end
+// of the main loop, and the signal following a vetoed one bypasses them so
+// that the app can always be stopped.
+func (a *App) RegisterShutdownVeto(veto ShutdownVeto) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shutdownVetoes = append(a.shutdownVetoes, veto)
+}
+
+// shutdownVetoed reports whether a registered veto opposes the shutdown.
+// Every veto is consulted.
+func (a *App) shutdownVetoed(ctx context.Context) bool {
+	a.mu.Lock()
+	vetoes := append([]ShutdownVeto(nil), a.shutdownVetoes...)
+	a.mu.Unlock()
+
+	vetoed := false
+	for _, veto := range vetoes {
+		if !veto(ctx) {
+			vetoed = true
+		}
+	}
+	return vetoed
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed is returned by WorkerPool.Submit once the pool stopped
+// accepting work.
+var ErrPoolClosed = errors.New("worker pool closed")
+
+// WorkerPool processes submitted items with a bounded number of workers.
+// Its Run method is a MainLoopFunc: when its context is canceled, the pool
+// stops accepting new items, processes or abandons the queued ones and waits
+// for the workers to finish, within the app shutdown budget.
+type WorkerPool[T any] struct {
+	workers int
+	handler func(context.Context, T) error
+	config  workerPoolConfig
+
+	queue chan T
+	// closing is closed once the pool stops accepting work, to unblock the
+	// Submit calls waiting for room in the queue.
+	closing   chan struct{}
+	closeOnce sync.Once
+	// mu guards the queue against being closed while items are sent on it.
+	mu        sync.RWMutex
+	closed    bool
+	abandoned atomic.Bool
+}
+
+type workerPoolConfig struct {
+	queueSize     int
+	drainTimeout  time.Duration
+	abandonQueued bool
+	onError       func(context.Context, error)
+}
+
+// WorkerPoolOption configures a WorkerPool.
+type WorkerPoolOption func(*workerPoolConfig)
+
+// WithPoolQueueSize sets how many submitted items may wait for a worker.
+// It defaults to the number of workers.
+func WithPoolQueueSize(size int) WorkerPoolOption {
+	return func(c *workerPoolConfig) {
+		c.queueSize = size
+	}
+}
+
+// WithPoolDrainTimeout bounds the time the pool waits for its workers once
+// its context is canceled. By default, the pool run as the app main loop
+// waits until the shutdown deadline, that is for the grace period plus
+// ShutdownTimeout, so that no item outlives the shutdown handlers closing
+// what it uses; elsewhere it waits without timeout. A negative duration
+// means no timeout.
+func WithPoolDrainTimeout(d time.Duration) WorkerPoolOption {
+	return func(c *workerPoolConfig) {
+		c.drainTimeout = d
+	}
+}
+
+// WithPoolAbandonQueued makes the pool drop the queued items once its
+// context is canceled, instead of processing them.
+func WithPoolAbandonQueued() WorkerPoolOption {
+	return func(c *workerPoolConfig) {
+		c.abandonQueued = true
+	}
+}
+
+// WithPoolErrorHandler sets the function called with the errors returned by
+// the item handler. By default they are logged with the logger of the
+// context given to Run, see LoggerFromContext.
+func WithPoolErrorHandler(onError func(context.Context, error)) WorkerPoolOption {
+	return func(c *workerPoolConfig) {
+		c.onError = onError
+	}
+}
+
+// NewWorkerPool creates a pool of n workers calling handler for each submitted item.
+func NewWorkerPool[T any](n int, handler func(context.Context, T) error, opts ...WorkerPoolOption) *WorkerPool[T] {
+	if n < 1 {
+		n = 1
+	}
+	config := workerPoolConfig{queueSize: n}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.onError == nil {
+		config.onError = func(ctx context.Context, err error) {
+			LoggerFromContext(ctx).Error("error processing worker pool item",
+				slog.String("module", "app/workerpool"),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+	return &WorkerPool[T]{
+		workers: n,
+		handler: handler,
+		config:  config,
+		queue:   make(chan T, max(config.queueSize, 0)),
+		closing: make(chan struct{}),
+	}
+}
+
+// Submit queues an item for processing, blocking while the queue is full.
+// It returns ErrPoolClosed once the pool stopped accepting work, including
+// when it does while Submit waits for room in the queue.
+func (p *WorkerPool[T]) Submit(item T) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.queue <- item:
+		return nil
+	case <-p.closing:
+		return ErrPoolClosed
+	}
+}
+
+// Run starts the workers and blocks until ctx is canceled and the pool is drained.
+// The items are processed with a context detached from ctx: it carries the
+// values of ctx, but is not canceled along with it, so that the items in
+// progress and the queued ones can complete once ctx is canceled. It is only
+// canceled when the drain timeout elapses, see WithPoolDrainTimeout, and
+// handlers should watch it to abandon their item then.
+func (p *WorkerPool[T]) Run(ctx context.Context) error {
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(workCtx)
+		}()
+	}
+
+	<-ctx.Done()
+	if p.config.abandonQueued {
+		p.abandoned.Store(true)
+	}
+	// The blocked Submit calls must return for the lock to be taken.
+	p.closeOnce.Do(func() { close(p.closing) })
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	drainTimeout := p.config.drainTimeout
+	if drainTimeout == 0 {
+		drainTimeout, _ = shutdownBudgetFromContext(ctx)
+	}
+	var timeout <-chan time.Time
+	if drainTimeout > 0 {
+		timer := time.NewTimer(drainTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-drained:
+		return nil
+	case <-timeout:
+		cancelWork()
+		return fmt.Errorf("worker pool drain timed out after %s: %w", drainTimeout, context.DeadlineExceeded)
+	}
+}
+
+// work processes queued items until the queue is closed and empty.
+func (p *WorkerPool[T]) work(ctx context.Context) {
+	for item := range p.queue {
+		if p.abandoned.Load() {
+			continue
+		}
+		if err := p.handler(ctx, item); err != nil {
+			p.config.onError(ctx, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baffau/baffau-go-devkit/app"
+	"github.com/baffau/baffau-go-devkit/app/apptest"
+)
+
+func TestWorkerPoolDrainStaysWithinShutdownBudget(t *testing.T) {
+	a := apptest.NewTestApp(t, app.WithShutdownTimeout(100*time.Millisecond))
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	pool := app.NewWorkerPool(1, func(ctx context.Context, _ int) error {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	}, app.WithPoolErrorHandler(func(context.Context, error) {}))
+
+	go func() {
+		pool.Submit(1)
+		<-started
+		a.TriggerShutdown("test")
+	}()
+	start := time.Now()
+	if err := a.RunE(context.Background(), pool.Run); err != nil {
+		t.Fatalf("RunE() = %v, want nil", err)
+	}
+
+	select {
+	case <-canceled:
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("item canceled after %s, want about the 100ms shutdown budget", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the item in progress outlived the shutdown budget")
+	}
+}
+
+func TestWorkerPoolCancelWithBlockedSubmit(t *testing.T) {
+	started := make(chan struct{}, 1)
+	pool := app.NewWorkerPool(1, func(ctx context.Context, _ int) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil
+	}, app.WithPoolQueueSize(1), app.WithPoolDrainTimeout(50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- pool.Run(ctx) }()
+	pool.Submit(1) // in progress
+	<-started
+	pool.Submit(2) // queued
+	blocked := make(chan error, 1)
+	go func() { blocked <- pool.Submit(3) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run() = %v, want the drain timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the drain timeout")
+	}
+	if err := <-blocked; !errors.Is(err, app.ErrPoolClosed) {
+		t.Errorf("blocked Submit() = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestWorkerPoolLogsErrorsWithTheContextLogger(t *testing.T) {
+	var buf syncBuffer
+	a := apptest.NewTestApp(t, app.WithLogOutput(&buf))
+	second := make(chan struct{})
+	pool := app.NewWorkerPool(1, func(_ context.Context, item int) error {
+		if item == 2 {
+			close(second)
+			return nil
+		}
+		return errors.New("item failed")
+	})
+
+	// RunE does not wait for the canceled main loop: its end is passed on.
+	loopDone := make(chan struct{})
+	go a.RunE(context.Background(), func(ctx context.Context) error {
+		defer close(loopDone)
+		return pool.Run(ctx)
+	})
+	pool.Submit(1)
+	pool.Submit(2)
+	// The single worker handled the error of the first item before the second.
+	<-second
+	if out := buf.String(); !strings.Contains(out, "error processing worker pool item") {
+		t.Errorf("the app logs miss the item error:\n%s", out)
+	}
+	a.TriggerShutdown("test")
+	<-loopDone
+}